@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/antick/ok/config"
+
+// settings holds the loaded configuration for handlers that need more than
+// their flags. main sets it with Configure before running any command.
+var settings config.Config
+
+// Configure makes cfg available to the command handlers.
+func Configure(cfg config.Config) {
+	settings = cfg
+}
@@ -1,11 +1,182 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/antick/ok/docker"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 func HandleDocker(cmd *cobra.Command, args []string) {
-	docker.RunDockerUI()
+	if err := docker.RunDockerUI(dockerOptions(cmd)); err != nil {
+		color.Red("Error: %v", err)
+	}
+}
+
+// dockerOptions picks the daemon from the --host and --context flags.
+func dockerOptions(cmd *cobra.Command) docker.Options {
+	host, _ := cmd.Flags().GetString("host")
+	context, _ := cmd.Flags().GetString("context")
+	return docker.Options{Shell: settings.Docker.Shell, Host: host, Context: context}
+}
+
+// HandleDockerPs implements `ok docker ps`.
+func HandleDockerPs(cmd *cobra.Command, args []string) {
+	all, _ := cmd.Flags().GetBool("all")
+	table, err := docker.ListContainers(dockerOptions(cmd), all)
+	if err != nil {
+		color.Red("Error listing containers: %v", err)
+		os.Exit(1)
+	}
+	if len(table.Rows) == 0 {
+		color.Yellow("No containers")
+		return
+	}
+	printDockerTable(table)
+}
+
+// HandleDockerLogs implements `ok docker logs <container|project>`.
+func HandleDockerLogs(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		color.Red("Error: specify one container or Compose project")
+		cmd.Usage()
+		os.Exit(2)
+	}
+	follow, _ := cmd.Flags().GetBool("follow")
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+	tail, _ := cmd.Flags().GetString("tail")
+
+	if err := docker.FollowLogs(dockerOptions(cmd), args[0], follow, timestamps, tail); err != nil {
+		color.Red("Error reading logs of %s: %v", args[0], err)
+		os.Exit(1)
+	}
+}
+
+// HandleDockerStop implements `ok docker stop <pattern>...`. It stops what it
+// can and exits with status 1 if a pattern matched nothing or a stop failed.
+func HandleDockerStop(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		color.Red("Error: specify the containers to stop")
+		cmd.Usage()
+		os.Exit(2)
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	failed := false
+	for _, pattern := range args {
+		err := docker.StopContainers(dockerOptions(cmd), pattern, timeout, func(name string, err error) {
+			if err != nil {
+				color.Red("Error stopping %s: %v", name, err)
+				failed = true
+			} else if verbose {
+				color.Green("Stopped %s", name)
+			} else {
+				fmt.Println(name)
+			}
+		})
+		if err != nil {
+			color.Red("Error: %v", err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// HandleDockerPrune implements `ok docker prune`.
+func HandleDockerPrune(cmd *cobra.Command, args []string) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	volumes, _ := cmd.Flags().GetBool("volumes")
+	yes, _ := cmd.Flags().GetBool("yes")
+	opts := dockerOptions(cmd)
+
+	if dryRun || !yes {
+		groups, err := docker.PruneCandidates(opts, volumes)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		empty := true
+		for _, group := range groups {
+			if len(group.Rows) == 0 {
+				continue
+			}
+			empty = false
+			color.Cyan("%s (%d):", group.What, len(group.Rows))
+			printDockerTable(group.Table)
+			fmt.Println()
+		}
+		if empty {
+			color.Green("Nothing to prune")
+			return
+		}
+		if dryRun || !confirm("Remove them?") {
+			return
+		}
+	}
+
+	results, err := docker.Prune(opts, volumes)
+	for _, result := range results {
+		color.Green(result)
+	}
+	if err != nil {
+		color.Red("Error pruning: %v", err)
+		os.Exit(1)
+	}
+}
+
+// HandleDockerStats implements `ok docker stats`.
+func HandleDockerStats(cmd *cobra.Command, args []string) {
+	noStream, _ := cmd.Flags().GetBool("no-stream")
+	err := docker.Stats(dockerOptions(cmd), !noStream, func(table docker.Table) {
+		if !noStream {
+			fmt.Print("\033[H\033[2J") // redraw in place, like docker stats
+		}
+		if len(table.Rows) == 0 {
+			color.Yellow("No running containers")
+			return
+		}
+		printDockerTable(table)
+	})
+	if err != nil {
+		color.Red("Error reading stats: %v", err)
+		os.Exit(1)
+	}
+}
+
+// printDockerTable prints a table with aligned columns under a yellow header.
+func printDockerTable(table docker.Table) {
+	widths := make([]int, len(table.Headers))
+	for i, header := range table.Headers {
+		widths[i] = len(header)
+	}
+	for _, row := range table.Rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	format := func(cells []string) string {
+		var b strings.Builder
+		for i, cell := range cells {
+			if i == len(cells)-1 {
+				b.WriteString(cell)
+				break
+			}
+			b.WriteString(cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+		}
+		return b.String()
+	}
+
+	color.Yellow(format(table.Headers))
+	for _, row := range table.Rows {
+		fmt.Println(format(row))
+	}
 }
@@ -65,14 +65,60 @@ func HandleHelp(c *cobra.Command, args []string) {
 	fmt.Println("  ok remove <file_or_directory> [-p|--permanent]")
 	fmt.Println("    Example: ok remove ./dist --permanent")
 	fmt.Println()
-	fmt.Println("  ok docker")
-	fmt.Println("    Launches an interactive UI to manage Docker containers.")
-	fmt.Println()
-	fmt.Println("  ok kill [--port] <port>")
-	fmt.Println("    macOS: Finds processes listening on the TCP port, lists them, and asks for confirmation.")
-	fmt.Println("    Confirmation prompt defaults to 'Y' on Enter. Kills with SIGKILL (-9) when confirmed.")
-	fmt.Println("    You can specify the port either as a flag (--port 3000) or as a positional argument (3000).")
-	fmt.Println("    Examples: ok kill --port 3000 or ok kill 3000")
+	fmt.Println("  ok docker [--host <url> | --context <name>]")
+	fmt.Println("    Launches an interactive UI to manage Docker containers, with live CPU, memory, network, block I/O and PID stats.")
+	fmt.Println("    Connects like the docker CLI (DOCKER_HOST, else the current context); --host also takes ssh://user@host.")
+	fmt.Println("    If the daemon is unreachable, explains why and reconnects when it's back (r retries at once).")
+	fmt.Println("    Keys: S start, s stop, r restart, p pause/unpause, K kill, D remove (destructive ones ask first).")
+	fmt.Println("    / searches by name, image or ID, a toggles all/running containers, o cycles the sort column, O reverses it.")
+	fmt.Println("    Enter follows the container's logs; tab focuses them: / filter, t timestamps, space pause, w save.")
+	fmt.Println("    Press i to inspect the selected container as a tree (secrets masked, y copies to the clipboard).")
+	fmt.Println("    Compose projects are grouped (space folds); on a project row S is up, s down, r restart, enter all logs.")
+	fmt.Println("    Type :images, :volumes or :networks to manage those (D delete, P prune), :containers to go back.")
+	fmt.Println("    Other commands: :filter <text>, :sort <column>, :start/:stop/:restart <name>, :logs <name>, :ctx [context|host], :help.")
+	fmt.Println("    In the prompt, up/down recall earlier commands and tab completes names.")
+	fmt.Println("    Press l to open a shell in the selected container (docker.shell in the config, default /bin/sh).")
+	fmt.Println()
+	fmt.Println("  ok docker ps [-a] | logs <name> [-f] | stop <pattern>... | prune [--dry-run] | stats [--no-stream]")
+	fmt.Println("    The dashboard's listings as plain commands for scripts, with the same columns and name matching.")
+	fmt.Println("    stop takes names, name or ID prefixes and globs (e.g. 'shop-*'); logs of a Compose project prefix each line")
+	fmt.Println("    with its service; prune removes stopped containers, dangling images and unused networks (--volumes too).")
+	fmt.Println()
+	fmt.Println("  ok kill [--port] <port>...")
+	fmt.Println("    Finds processes listening on the TCP port, lists them, and asks for confirmation.")
+	fmt.Println("    Reads /proc directly on Linux and uses lsof on macOS.")
+	fmt.Println("    Confirmation prompt defaults to 'Y' on Enter. With several processes on a terminal, pick which")
+	fmt.Println("    to kill instead: arrows move, space toggles, enter confirms, q aborts.")
+	fmt.Println("    Sends SIGTERM, waits up to --timeout (default 5s)")
+	fmt.Println("    for the port to be released, then sends SIGKILL to any survivors.")
+	fmt.Println("    Use --signal <name|number> to send a single signal instead, e.g. --signal KILL.")
+	fmt.Println("    You can specify ports either as flags (--port 3000) or as positional arguments (3000).")
+	fmt.Println("    Several ports and ranges are gathered into one table and confirmed once: ok kill 3000 5173 8080-8090")
+	fmt.Println("    If a Docker container publishes the port, offers to stop the container instead of killing docker-proxy.")
+	fmt.Println("    --tree also kills the listener's descendants; --parent walks up to the supervising process")
+	fmt.Println("    (e.g. 'npm run dev' or nodemon) so it cannot respawn the listener, and kills its whole tree.")
+	fmt.Println("    --udp looks at UDP sockets, --all-states adds non-listening TCP sockets and connected clients,")
+	fmt.Println("    and --ipv4/--ipv6 (-4/-6) restrict the address family. These also apply to wait-port.")
+	fmt.Println("    Examples: ok kill --port 3000, ok kill 3000 or ok kill 3000 --timeout 10s")
+	fmt.Println()
+	fmt.Println("  ok kill --name <name> | --pid <pid> | --match <regex>")
+	fmt.Println("    Selects processes by name, PID or a pattern on the full command line instead of a port.")
+	fmt.Println("    Use --yes (-y) to skip the confirmation prompt in scripts.")
+	fmt.Println("    Examples: ok kill --name node, ok kill --pid 1234, ok kill --match 'webpack.*serve' -y")
+	fmt.Println()
+
+	fmt.Println("  ok kill ... --force")
+	fmt.Println("    Protected processes (sshd, systemd, launchd, Docker daemons, other users' root processes, port 22)")
+	fmt.Println("    are skipped unless --force is given. Adjust kill.protected / kill.allowed in ~/.ok/config.yaml.")
+	fmt.Println()
+	fmt.Println("  ok kill --history [--limit 20]")
+	fmt.Println("    Every kill is recorded in ~/.ok/history (time, user, host, ports, PIDs, commands, signal, result).")
+	fmt.Println("    --history shows the most recent entries.")
+	fmt.Println()
+	fmt.Println("  ok wait-port <port> [--free|--listening] [--timeout 30s] [--http <path>] [--udp]")
+	fmt.Println("    Blocks until the port is listening (default) or free. --http also waits for an HTTP GET")
+	fmt.Println("    of the path on localhost to succeed. Exits with status 1 on timeout.")
+	fmt.Println("    Examples: ok wait-port 3000 --http /health, ok kill 3000 -y && ok wait-port 3000 --free")
 	fmt.Println()
 
 	color.Yellow("Config:")
@@ -1,145 +1,523 @@
 package cmd
 
 import (
-    "bufio"
-    "bytes"
-    "fmt"
-    "os"
-    "os/exec"
-    "strconv"
-    "strings"
-    "syscall"
-
-    "github.com/fatih/color"
-    "github.com/spf13/cobra"
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/antick/ok/process"
+	"github.com/antick/ok/utils"
 )
 
 type processInfo struct {
 	PID     int
 	Command string
 	User    string
-	Name    string // NAME column from lsof (e.g., *:3000 or 127.0.0.1:3000)
+	Ports   []int // matched ports, empty for processes selected otherwise
+	Sockets []process.Socket
+	Args    string // full command line
+	Depth   int    // nesting level when shown as part of a process tree
 }
 
-// HandleKill implements `ok kill --port <port>` or `ok kill <port>`
+// HandleKill implements `ok kill --port <port>` or `ok kill <port>...`, and
+// `ok kill --name/--pid/--match` to select processes directly.
 func HandleKill(cmd *cobra.Command, args []string) {
-    port, _ := cmd.Flags().GetInt("port")
-    
-    // If no port flag provided, check if first argument is a port number
-    if port == 0 && len(args) > 0 {
-        if p, err := strconv.Atoi(args[0]); err == nil {
-            port = p
-        }
-    }
-    
-    // If still no port, show error and help
-    if port == 0 {
-        color.Red("Error: No port provided. Use --port <port> or ok kill <port>.")
-        // Show full help so the user can see how to use this command
-        _ = cmd.Root().Help()
-        return
-    }
-
-	verbose, _ := cmd.Flags().GetBool("verbose")
-
-	procs, err := findProcessesOnPort(port)
-	if err != nil {
-		color.Red("Error finding processes on port %d: %v", port, err)
+	if history, _ := cmd.Flags().GetBool("history"); history {
+		limit, _ := cmd.Flags().GetInt("limit")
+		showKillHistory(limit)
 		return
 	}
-	if len(procs) == 0 {
-		color.Yellow("No processes found listening on port %d", port)
-		return
+
+	opts := killOptions{}
+	opts.timeout, _ = cmd.Flags().GetDuration("timeout")
+	if name, _ := cmd.Flags().GetString("signal"); name != "" {
+		sig, err := process.ParseSignal(name)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		opts.signal = sig
 	}
-	color.Cyan("Found %d process(es) using port %d:", len(procs), port)
-	printProcessTable(procs)
 
-	fmt.Print("Proceed to kill them? [Y/n]: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
-	if input != "" && (strings.EqualFold(input, "n") || strings.EqualFold(input, "no")) {
-		color.Yellow("Aborted.")
-		return
+	names, _ := cmd.Flags().GetStringSlice("name")
+	pids, _ := cmd.Flags().GetIntSlice("pid")
+	pattern, _ := cmd.Flags().GetString("match")
+
+	var (
+		procs   []processInfo
+		ports   []int
+		target  string // the selection as recorded in the history
+		what    string // describes the selection in messages
+		success string
+		holding func() map[int]bool
+		// hidden holds sockets on the ports whose processes we can't see
+		hidden *processInfo
+		err    error
+	)
+	if len(names) > 0 || len(pids) > 0 || pattern != "" {
+		filter, err := newProcessFilter(names, pids, pattern)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		procs, err = findProcessesMatching(filter)
+		if err != nil {
+			color.Red("Error finding processes: %v", err)
+			return
+		}
+		if len(procs) == 0 {
+			color.Yellow("No processes found matching %s", filter)
+			return
+		}
+		target = filter.String()
+		what = "matching " + target
+	} else {
+		// Ports come from --port and from positional arguments, and may be
+		// ranges such as 8080-8090
+		specs, _ := cmd.Flags().GetStringSlice("port")
+		ports, err = utils.ParsePorts(append(specs, args...))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		// If still no port, show error and help
+		if len(ports) == 0 {
+			color.Red("Error: No port provided. Use --port <port>, ok kill <port>... or --name/--pid/--match.")
+			// Show full help so the user can see how to use this command
+			_ = cmd.Root().Help()
+			return
+		}
+
+		query := socketQuery(cmd, ports)
+		label := portsLabel(query)
+		procs, err = findProcessesOnPorts(query)
+		if err != nil {
+			color.Red("Error finding processes on %s: %v", label, err)
+			return
+		}
+		procs, hidden = hiddenHolder(procs)
+
+		// Containers are stopped through Docker rather than by killing
+		// docker-proxy, which would leave Docker's port bookkeeping broken
+		yes, _ := cmd.Flags().GetBool("yes")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		target = label
+		if running, handled := offerContainerStop(query, opts.timeout, yes, verbose); handled {
+			if len(running) > 0 {
+				// Go on with the ports no running container publishes
+				if query.Ports = withoutPorts(query.Ports, running); len(query.Ports) == 0 {
+					return
+				}
+				ports, label = query.Ports, portsLabel(query)
+				target = label
+			}
+			if procs, err = findProcessesOnPorts(query); err != nil {
+				color.Red("Error finding processes on %s: %v", label, err)
+				return
+			}
+			procs, hidden = hiddenHolder(procs)
+			if len(running) == 0 {
+				if len(procs) == 0 && hidden == nil {
+					color.Green("Successfully freed %s", label)
+					return
+				}
+				color.Yellow("Processes outside Docker still use %s", label)
+			}
+		}
+
+		if len(procs) == 0 && hidden != nil {
+			color.Yellow("A process you cannot see is using %s (try sudo)", label)
+			return
+		}
+		if len(procs) == 0 {
+			if query.Proto == process.UDP || query.AllStates {
+				color.Yellow("No processes found using %s", label)
+			} else {
+				color.Yellow("No processes found listening on %s", label)
+			}
+			return
+		}
+		what = "using " + label
+		success = "Successfully freed " + label
+		if hidden != nil {
+			// Killing what we see won't free the port
+			color.Yellow("A process you cannot see is also using %s (try sudo)", label)
+			success = ""
+		}
+		holding = func() map[int]bool {
+			holding, err := findProcessesOnPorts(query)
+			if err != nil {
+				return nil
+			}
+			pids := map[int]bool{}
+			for _, p := range holding {
+				pids[p.PID] = true
+			}
+			return pids
+		}
 	}
 
+	tree, _ := cmd.Flags().GetBool("tree")
+	parent, _ := cmd.Flags().GetBool("parent")
+	if tree || parent {
+		selected := len(procs)
+		procs, err = expandProcessTree(procs, parent)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if len(procs) == 0 {
+			color.Yellow("Nothing left to kill: the tree only holds ok itself and the shell that started it")
+			return
+		}
+		color.Cyan("Found %d process(es) %s; %d process(es) in their tree:", selected, what, len(procs))
+	} else {
+		color.Cyan("Found %d process(es) %s:", len(procs), what)
+	}
+
+	if force, _ := cmd.Flags().GetBool("force"); !force {
+		allowed, protected, reasons := partitionProtected(procs, settings.Kill)
+		if len(protected) > 0 {
+			printProcessTable(procs)
+			for i, p := range protected {
+				color.Red("Refusing to kill protected process %d (%s): %s", p.PID, p.Command, reasons[i])
+			}
+			if len(allowed) == 0 {
+				color.Yellow("Nothing left to kill. Use --force to override the protection (see kill.protected in ~/.ok/config.yaml).")
+				return
+			}
+			color.Yellow("Skipping them; use --force to override. Remaining process(es):")
+			procs = allowed
+			success = ""
+		}
+	}
+
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		printProcessTable(procs)
+	} else {
+		chosen := chooseProcesses(procs)
+		if len(chosen) == 0 {
+			color.Yellow("Aborted.")
+			return
+		}
+		if len(chosen) < len(procs) {
+			// Some holders are left running, so the port may not be free
+			success = ""
+		}
+		procs = chosen
+	}
+	if success == "" {
+		success = fmt.Sprintf("Successfully killed %d process(es)", len(procs))
+	}
+
+	results := killProcesses(procs, opts, holding)
+	recordKill(newKillRecord(target, ports, opts, results))
+
 	var failed []int
-	for _, p := range procs {
-		if err := syscall.Kill(p.PID, syscall.SIGKILL); err != nil {
-			failed = append(failed, p.PID)
-		} else if verbose {
-			color.Green("Killed PID %d (%s)", p.PID, p.Command)
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.proc.PID)
+			color.Red("PID %d (%s): %v", r.proc.PID, r.proc.Command, r.err)
+		} else {
+			color.Green("PID %d (%s): %s", r.proc.PID, r.proc.Command, r.step)
 		}
 	}
 
 	if len(failed) == 0 {
-		color.Green("Successfully freed port %d", port)
+		color.Green(success)
 	} else {
 		color.Yellow("Some processes could not be killed: %v", failed)
 	}
 }
 
-func findProcessesOnPort(port int) ([]processInfo, error) {
-    c := exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN")
-    var out bytes.Buffer
-    c.Stdout = &out
-    c.Stderr = &out
-    _ = c.Run() // lsof may return non-zero when nothing is found; parse output regardless
+// confirmKill asks whether to go ahead; Enter defaults to yes.
+func confirmKill() bool {
+	return confirm("Proceed to kill them?")
+}
 
-	_lines := strings.Split(out.String(), "\n")
-	var res []processInfo
-	for i, line := range _lines {
-		line = strings.TrimSpace(line)
-		if i == 0 && strings.HasPrefix(strings.ToUpper(line), "COMMAND") {
-			continue
+// confirm asks a yes/no question on stdin; Enter defaults to yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [Y/n]: ", question)
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	return input == "" || !(strings.EqualFold(input, "n") || strings.EqualFold(input, "no"))
+}
+
+// killOptions controls how killProcesses signals its targets.
+type killOptions struct {
+	// signal is sent once without waiting. Zero selects the graceful mode:
+	// SIGTERM, wait up to timeout, then SIGKILL for survivors.
+	signal  syscall.Signal
+	timeout time.Duration
+}
+
+// killResult records which step ended a process, or why it could not be ended.
+type killResult struct {
+	proc processInfo
+	step string
+	err  error
+}
+
+// killPollInterval is how often the graceful mode checks for exited processes.
+const killPollInterval = 100 * time.Millisecond
+
+// killProcesses signals procs according to opts. holding reports which PIDs
+// still hold the resource being freed; a listener that releases it during the
+// graceful wait is not escalated to SIGKILL. Entries without ports (members
+// of a process tree) are waited on until they exit. A nil holding, or a nil
+// map from it, means only process liveness is considered.
+func killProcesses(procs []processInfo, opts killOptions, holding func() map[int]bool) []killResult {
+	results := make([]killResult, len(procs))
+	for i, p := range procs {
+		results[i].proc = p
+	}
+
+	if opts.signal != 0 {
+		for i, p := range procs {
+			if err := syscall.Kill(p.PID, opts.signal); err != nil {
+				results[i].err = fmt.Errorf("could not send %s: %w", process.SignalName(opts.signal), err)
+			} else {
+				results[i].step = "sent " + process.SignalName(opts.signal)
+			}
 		}
-		fields := strings.Fields(line)
-		if len(fields) < 8 {
+		return results
+	}
+
+	var pending []int
+	for i, p := range procs {
+		if err := syscall.Kill(p.PID, syscall.SIGTERM); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				results[i].step = "already exited"
+			} else {
+				results[i].err = fmt.Errorf("could not send SIGTERM: %w", err)
+			}
 			continue
 		}
-		pid, err := strconv.Atoi(fields[1])
-		if err != nil {
-			continue
+		pending = append(pending, i)
+	}
+
+	// survivors returns the pending processes that are still alive and, as far
+	// as we can tell, still holding the resource.
+	survivors := func() []int {
+		var held map[int]bool
+		if holding != nil {
+			held = holding()
 		}
-		// NAME is typically the last field, with optional trailing "(LISTEN)" token
-		name := fields[len(fields)-1]
-		if name == "(LISTEN)" && len(fields) >= 2 {
-			name = fields[len(fields)-2]
+		var left []int
+		for _, i := range pending {
+			pid := procs[i].PID
+			switch {
+			case !process.Alive(pid):
+				results[i].step = "exited after SIGTERM"
+			case held != nil && len(procs[i].Ports) > 0 && !held[pid]:
+				results[i].step = "released port after SIGTERM"
+			default:
+				left = append(left, i)
+			}
 		}
-		res = append(res, processInfo{
-			PID:     pid,
-			Command: fields[0],
-			User:    fields[2],
-			Name:    name,
-		})
+		return left
+	}
+
+	deadline := time.Now().Add(opts.timeout)
+	for len(pending) > 0 {
+		if pending = survivors(); len(pending) == 0 || !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(killPollInterval)
+	}
+
+	for _, i := range pending {
+		pid := procs[i].PID
+		err := syscall.Kill(pid, syscall.SIGKILL)
+		switch {
+		case errors.Is(err, syscall.ESRCH):
+			// It exited between the last poll and now
+			results[i].step = "exited after SIGTERM"
+		case err != nil:
+			results[i].err = fmt.Errorf("still running after %s; could not send SIGKILL: %w", opts.timeout, err)
+		default:
+			results[i].step = "killed with SIGKILL"
+		}
+	}
+	return results
+}
+
+// socketQuery builds the socket lookup for ports from the --udp,
+// --all-states, --ipv4 and --ipv6 flags.
+func socketQuery(cmd *cobra.Command, ports []int) process.Query {
+	q := process.Query{Ports: ports, Proto: process.TCP}
+	if udp, _ := cmd.Flags().GetBool("udp"); udp {
+		q.Proto = process.UDP
+	}
+	q.AllStates, _ = cmd.Flags().GetBool("all-states")
+	v4, _ := cmd.Flags().GetBool("ipv4")
+	v6, _ := cmd.Flags().GetBool("ipv6")
+	switch {
+	case v4 && v6:
+		// Both is the same as neither
+	case v4:
+		q.Family = process.IPv4
+	case v6:
+		q.Family = process.IPv6
+	}
+	return q
+}
+
+// portsLabel describes the ports of q for messages, e.g. "UDP port 5353".
+func portsLabel(q process.Query) string {
+	label := "port " + utils.FormatPorts(q.Ports)
+	if len(q.Ports) > 1 {
+		label = "ports " + utils.FormatPorts(q.Ports)
+	}
+	if q.Proto == process.UDP {
+		label = "UDP " + label
+	}
+	if q.Family != process.AnyFamily {
+		label = q.Family.String() + " " + label
+	}
+	return label
+}
+
+// findProcessesOnPorts returns one entry per process holding a socket that
+// matches q, listing every matched port and socket. Sockets whose holder we
+// can't see are gathered in an entry with PID 0, last.
+func findProcessesOnPorts(q process.Query) ([]processInfo, error) {
+	sockets, err := process.Default().Sockets(q)
+	if err != nil {
+		return nil, err
 	}
 
 	// Deduplicate by PID
-	seen := map[int]bool{}
-	uniq := make([]processInfo, 0, len(res))
-	for _, p := range res {
-		if !seen[p.PID] {
-			seen[p.PID] = true
-			uniq = append(uniq, p)
+	index := map[int]int{}
+	uniq := make([]processInfo, 0, len(sockets))
+	for _, sock := range sockets {
+		i, seen := index[sock.PID]
+		if !seen {
+			i = len(uniq)
+			index[sock.PID] = i
+			uniq = append(uniq, processInfo{
+				PID:     sock.PID,
+				Command: sock.Command,
+				User:    sock.User,
+			})
+		}
+		p := &uniq[i]
+		port := sock.Local.Port
+		if !containsPort(q.Ports, port) && sock.Remote != nil {
+			// A client connected to one of the ports (--all-states)
+			port = sock.Remote.Port
 		}
+		if !containsPort(p.Ports, port) {
+			p.Ports = append(p.Ports, port)
+			sort.Ints(p.Ports)
+		}
+		p.Sockets = append(p.Sockets, sock)
 	}
+	sort.SliceStable(uniq, func(i, j int) bool { return uniq[j].PID == 0 && uniq[i].PID != 0 })
 	return uniq, nil
 }
 
+// hiddenHolder splits off the entry of findProcessesOnPorts for sockets held
+// by processes we can't see. Those can't be killed, and PID 0 must never
+// reach kill(2), which would signal ok's own process group.
+func hiddenHolder(procs []processInfo) (visible []processInfo, hidden *processInfo) {
+	if n := len(procs); n > 0 && procs[n-1].PID == 0 {
+		return procs[:n-1], &procs[n-1]
+	}
+	return procs, nil
+}
+
+// socketNames joins the distinct socket names of p for the NAME column.
+// IPv4 and IPv6 wildcard listeners both print as *:<port> and appear once.
+func socketNames(p processInfo) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, sock := range p.Sockets {
+		name := sock.String()
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
 func printProcessTable(procs []processInfo) {
+	header, rows := formatProcessTable(procs)
+	color.Yellow(header)
+	for _, row := range rows {
+		fmt.Println(row)
+	}
+}
+
+// formatProcessTable lays out procs as aligned text rows under a header.
+func formatProcessTable(procs []processInfo) (string, []string) {
 	cmdWidth := len("COMMAND")
 	userWidth := len("USER")
 	for _, p := range procs {
-		if len(p.Command) > cmdWidth {
-			cmdWidth = len(p.Command)
+		if w := utf8.RuneCountInString(treeLabel(p)); w > cmdWidth {
+			cmdWidth = w
 		}
 		if len(p.User) > userWidth {
 			userWidth = len(p.User)
 		}
 	}
-	header := fmt.Sprintf("%-*s  %-*s  %-5s  %s", cmdWidth, "COMMAND", userWidth, "USER", "PID", "NAME")
-	color.Yellow(header)
+	// Show socket addresses when we have them, otherwise the command lines
+	// so that --name and --match selections can be told apart
+	last, lastHeader := func(p processInfo) string { return p.Args }, "ARGS"
 	for _, p := range procs {
-		fmt.Printf("%-*s  %-*s  %-5d  %s\n", cmdWidth, p.Command, userWidth, p.User, p.PID, p.Name)
+		if len(p.Sockets) > 0 {
+			last, lastHeader = socketNames, "NAME"
+			break
+		}
+	}
+	// The PORT column only appears for port-based selections
+	portWidth := 0
+	ports := make([]string, len(procs))
+	for i, p := range procs {
+		ports[i] = utils.FormatPorts(p.Ports)
+		if len(ports[i]) > portWidth {
+			portWidth = len(ports[i])
+		}
+	}
+	portCol := func(s string) string { return "" }
+	if portWidth > 0 {
+		portWidth = max(portWidth, len("PORT"))
+		portCol = func(s string) string { return fmt.Sprintf("%-*s  ", portWidth, s) }
+	}
+
+	header := fmt.Sprintf("%-*s  %-*s  %-5s  %s%s", cmdWidth, "COMMAND", userWidth, "USER", "PID", portCol("PORT"), lastHeader)
+	rows := make([]string, len(procs))
+	for i, p := range procs {
+		rows[i] = fmt.Sprintf("%-*s  %-*s  %-5d  %s%s", cmdWidth, treeLabel(p), userWidth, p.User, p.PID, portCol(ports[i]), last(p))
+	}
+	return header, rows
+}
+
+// treeLabel indents the command of a process tree member under its parent.
+func treeLabel(p processInfo) string {
+	if p.Depth == 0 {
+		return p.Command
 	}
+	return strings.Repeat("   ", p.Depth-1) + "└─ " + p.Command
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/antick/ok/docker"
+	"github.com/antick/ok/process"
+	"github.com/antick/ok/utils"
+)
+
+// offerContainerStop looks for running containers publishing the ports of q
+// and offers to stop them instead of killing processes. handled reports
+// whether any were found. running lists the ports of containers left running,
+// declined or failed to stop: the caller must leave those ports alone, since
+// killing docker-proxy instead would break Docker's port forwarding. When
+// Docker is not available the lookup is skipped silently.
+func offerContainerStop(q process.Query, timeout time.Duration, yes, verbose bool) (running []int, handled bool) {
+	containers, err := docker.ContainersPublishing(q.Ports, strings.ToLower(string(q.Proto)))
+	if err != nil {
+		if verbose {
+			color.Yellow("Skipping Docker container lookup: %v", err)
+		}
+		return nil, false
+	}
+	if len(containers) == 0 {
+		return nil, false
+	}
+
+	color.Cyan("Found %d Docker container(s) publishing %s:", len(containers), portsLabel(q))
+	nameWidth := len("CONTAINER")
+	imageWidth := len("IMAGE")
+	for _, c := range containers {
+		nameWidth = max(nameWidth, len(c.Name))
+		imageWidth = max(imageWidth, len(c.Image))
+	}
+	color.Yellow("%-*s  %-12s  %-*s  %s", nameWidth, "CONTAINER", "ID", imageWidth, "IMAGE", "PORT")
+	for _, c := range containers {
+		fmt.Printf("%-*s  %-12s  %-*s  %s\n", nameWidth, c.Name, c.ID, imageWidth, c.Image, utils.FormatPorts(c.Ports))
+	}
+
+	if !yes && !confirm("Stop the container(s) instead of killing processes?") {
+		for _, c := range containers {
+			running = append(running, c.Ports...)
+		}
+		if len(withoutPorts(q.Ports, running)) == 0 {
+			color.Yellow("Aborted. Nothing was killed: killing docker-proxy would break Docker's port forwarding.")
+		} else {
+			color.Yellow("Leaving %s alone: killing docker-proxy would break Docker's port forwarding.", portsLabel(process.Query{Ports: running, Proto: q.Proto}))
+		}
+		return running, true
+	}
+
+	record := newKillRecord(portsLabel(q), q.Ports, killOptions{timeout: timeout}, nil)
+	record.Signal = "docker stop"
+	for _, c := range containers {
+		entry := killedProcess{Command: "container " + c.Name, Result: "stopped"}
+		if err := docker.StopContainer(c.ID, timeout); err != nil {
+			color.Red("Container %s: %v", c.Name, err)
+			running = append(running, c.Ports...)
+			entry.Result = ""
+			entry.Error = err.Error()
+		} else {
+			color.Green("Container %s: stopped", c.Name)
+		}
+		record.Processes = append(record.Processes, entry)
+	}
+	recordKill(record)
+	if len(running) > 0 {
+		color.Yellow("Some containers could not be stopped.")
+	}
+	return running, true
+}
+
+// withoutPorts returns ports minus those in drop.
+func withoutPorts(ports, drop []int) []int {
+	var res []int
+	for _, p := range ports {
+		if !slices.Contains(drop, p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/antick/ok/config"
+	"github.com/antick/ok/process"
+	"github.com/antick/ok/utils"
+)
+
+// killRecord is one `ok kill` action in ~/.ok/history, stored as a JSON line.
+type killRecord struct {
+	Time      time.Time       `json:"time"`
+	User      string          `json:"user"`
+	Host      string          `json:"host"`
+	Target    string          `json:"target"` // e.g. "port 3000" or "name node"
+	Ports     []int           `json:"ports,omitempty"`
+	Signal    string          `json:"signal"`
+	Processes []killedProcess `json:"processes"`
+}
+
+// killedProcess is the outcome for one process (or container) of a killRecord.
+type killedProcess struct {
+	PID     int    `json:"pid,omitempty"`
+	Command string `json:"command"`
+	Args    string `json:"args,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+	Result  string `json:"result,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newKillRecord(target string, ports []int, opts killOptions, results []killResult) killRecord {
+	r := killRecord{
+		Time:   time.Now(),
+		User:   currentUser(),
+		Target: target,
+		Ports:  ports,
+		Signal: "SIGTERM, SIGKILL after " + opts.timeout.String(),
+	}
+	r.Host, _ = os.Hostname()
+	if opts.signal != 0 {
+		r.Signal = process.SignalName(opts.signal)
+	}
+	for _, res := range results {
+		entry := killedProcess{
+			PID:     res.proc.PID,
+			Command: res.proc.Command,
+			Args:    res.proc.Args,
+			Owner:   res.proc.User,
+			Result:  res.step,
+		}
+		if res.err != nil {
+			entry.Error = res.err.Error()
+		}
+		r.Processes = append(r.Processes, entry)
+	}
+	return r
+}
+
+// currentUser names who ran ok, including the invoking user under sudo.
+func currentUser() string {
+	name := "?"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if sudo := os.Getenv("SUDO_USER"); sudo != "" && sudo != name {
+		name += " (sudo by " + sudo + ")"
+	}
+	return name
+}
+
+func historyPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history"), nil
+}
+
+// recordKill appends r to the history. Failing to record never fails the
+// kill itself; it only prints a warning.
+func recordKill(r killRecord) {
+	if err := appendHistory(r); err != nil {
+		color.Yellow("Warning: could not record kill history: %v", err)
+	}
+}
+
+func appendHistory(r killRecord) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening history: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing history: %w", err)
+	}
+	return nil
+}
+
+func readHistory() ([]killRecord, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening history: %w", err)
+	}
+	defer f.Close()
+
+	var records []killRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r killRecord
+		// Skip lines we cannot parse rather than hide the whole history
+		if err := json.Unmarshal(scanner.Bytes(), &r); err == nil {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// showKillHistory prints the last limit kill actions, oldest first, one row
+// per process. A limit of 0 or less shows everything.
+func showKillHistory(limit int) {
+	records, err := readHistory()
+	if err != nil {
+		color.Red("Error reading kill history: %v", err)
+		return
+	}
+	if len(records) == 0 {
+		color.Yellow("No kill history yet")
+		return
+	}
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	type row struct{ time, user, port, pid, command, signal, result string }
+	rows := []row{{"TIME", "USER", "PORT", "PID", "COMMAND", "SIGNAL", "RESULT"}}
+	for _, r := range records {
+		port := utils.FormatPorts(r.Ports)
+		if port == "" {
+			port = "-"
+		}
+		for _, p := range r.Processes {
+			pid := "-"
+			if p.PID != 0 {
+				pid = fmt.Sprint(p.PID)
+			}
+			result := p.Result
+			if p.Error != "" {
+				result = "failed: " + p.Error
+			}
+			rows = append(rows, row{r.Time.Local().Format("2006-01-02 15:04:05"), r.User, port, pid, p.Command, r.Signal, result})
+		}
+	}
+
+	var widths [6]int
+	for _, r := range rows {
+		for i, v := range []string{r.time, r.user, r.port, r.pid, r.command, r.signal} {
+			widths[i] = max(widths[i], len(v))
+		}
+	}
+	for i, r := range rows {
+		line := fmt.Sprintf("%-*s  %-*s  %-*s  %-*s  %-*s  %-*s  %s",
+			widths[0], r.time, widths[1], r.user, widths[2], r.port, widths[3], r.pid,
+			widths[4], r.command, widths[5], r.signal, r.result)
+		if i == 0 {
+			color.Yellow(line)
+		} else {
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// chooseProcesses shows procs and returns the ones the user wants killed, or
+// nil to abort. With several processes on a terminal it shows an interactive
+// picker; otherwise it prints the table and falls back to the Y/n prompt.
+func chooseProcesses(procs []processInfo) []processInfo {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if len(procs) > 1 && term.IsTerminal(in) && term.IsTerminal(out) {
+		if chosen, err := pickProcesses(procs, in); err == nil {
+			return chosen
+		}
+		// Could not switch the terminal to raw mode; use the plain prompt
+	}
+
+	printProcessTable(procs)
+	if !confirmKill() {
+		return nil
+	}
+	return procs
+}
+
+// pickProcesses runs the interactive multi-select. Everything starts selected
+// so that Enter alone behaves like answering Y.
+func pickProcesses(procs []processInfo, fd int) ([]processInfo, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(fd, state)
+
+	header, rows := formatProcessTable(procs)
+	selected := make([]bool, len(procs))
+	for i := range selected {
+		selected[i] = true
+	}
+	cursor := 0
+
+	highlight := color.New(color.FgCyan, color.Bold).SprintFunc()
+	dim := color.New(color.Faint).SprintFunc()
+
+	// In raw mode "\n" only moves down, so every line ends with "\r\n"
+	draw := func(first bool) {
+		var b strings.Builder
+		if !first {
+			fmt.Fprintf(&b, "\x1b[%dA", len(rows)+2)
+		}
+		fmt.Fprintf(&b, "\r\x1b[2K%s\r\n", dim("↑/↓ move  space toggle  a all/none  enter kill selected  q abort"))
+		fmt.Fprintf(&b, "\r\x1b[2K%s\r\n", color.YellowString("     %s", header))
+		for i, row := range rows {
+			box := "[ ]"
+			if selected[i] {
+				box = "[x]"
+			}
+			line := fmt.Sprintf("  %s %s", box, row)
+			if i == cursor {
+				line = highlight(">" + line[1:])
+			}
+			fmt.Fprintf(&b, "\r\x1b[2K%s\r\n", line)
+		}
+		fmt.Print(b.String())
+	}
+
+	draw(true)
+	buf := make([]byte, 8)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, nil
+		}
+		switch key := string(buf[:n]); key {
+		case "\x1b[A", "k":
+			if cursor > 0 {
+				cursor--
+			}
+		case "\x1b[B", "j":
+			if cursor < len(rows)-1 {
+				cursor++
+			}
+		case " ", "x":
+			selected[cursor] = !selected[cursor]
+		case "a":
+			all := true
+			for _, s := range selected {
+				all = all && s
+			}
+			for i := range selected {
+				selected[i] = !all
+			}
+		case "\r", "\n":
+			var chosen []processInfo
+			for i, p := range procs {
+				if selected[i] {
+					chosen = append(chosen, p)
+				}
+			}
+			return chosen, nil
+		case "q", "\x1b", "\x03", "\x04": // q, Esc, Ctrl-C, Ctrl-D
+			return nil, nil
+		}
+		draw(false)
+	}
+}
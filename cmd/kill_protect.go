@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/antick/ok/config"
+)
+
+// protectedReason explains why p must not be killed without --force, or
+// returns "" when it may be. Allowed rules win over Protected ones.
+func protectedReason(p processInfo, rules config.KillConfig) string {
+	if p.PID <= 1 {
+		return "PID 1 is the init process"
+	}
+	if matchRules(p, rules.Allowed) != "" {
+		return ""
+	}
+	return matchRules(p, rules.Protected)
+}
+
+// matchRules returns a description of the first rule in r matching p.
+func matchRules(p processInfo, r config.ProcessRules) string {
+	exe := filepath.Base(strings.SplitN(p.Args, " ", 2)[0])
+	for _, name := range r.Commands {
+		if p.Command == name || exe == name {
+			return "command " + name
+		}
+	}
+	for _, port := range r.Ports {
+		if containsPort(p.Ports, port) {
+			return fmt.Sprintf("port %d", port)
+		}
+	}
+	for _, name := range r.Users {
+		if p.User == name && !ownProcess(p) {
+			return "owned by " + name
+		}
+	}
+	return ""
+}
+
+// ownProcess reports whether p belongs to the user running ok. Under sudo
+// that user is root, but root's processes are still not "yours".
+func ownProcess(p processInfo) bool {
+	if os.Getenv("SUDO_USER") != "" {
+		return false
+	}
+	u, err := user.Current()
+	return err == nil && u.Username == p.User
+}
+
+// partitionProtected splits procs into those that may be killed and those
+// protected by rules, with the reason for each protected one.
+func partitionProtected(procs []processInfo, rules config.KillConfig) (allowed, protected []processInfo, reasons []string) {
+	for _, p := range procs {
+		if reason := protectedReason(p, rules); reason != "" {
+			protected = append(protected, p)
+			reasons = append(reasons, reason)
+		} else {
+			allowed = append(allowed, p)
+		}
+	}
+	return allowed, protected, reasons
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/antick/ok/process"
+)
+
+// processFilter selects processes by name, PID or command-line pattern.
+// A process is selected when it matches any of the criteria.
+type processFilter struct {
+	names   []string
+	pids    []int
+	pattern *regexp.Regexp
+}
+
+func newProcessFilter(names []string, pids []int, pattern string) (processFilter, error) {
+	f := processFilter{names: names, pids: pids}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return f, fmt.Errorf("invalid --match pattern: %w", err)
+		}
+		f.pattern = re
+	}
+	return f, nil
+}
+
+func (f processFilter) matches(p process.Process) bool {
+	for _, pid := range f.pids {
+		if p.PID == pid {
+			return true
+		}
+	}
+	// Linux truncates process names to 15 characters, so also compare the
+	// executable from the command line
+	exe := filepath.Base(strings.SplitN(p.Args, " ", 2)[0])
+	for _, name := range f.names {
+		if p.Command == name || exe == name {
+			return true
+		}
+	}
+	return f.pattern != nil && f.pattern.MatchString(p.Args)
+}
+
+func (f processFilter) String() string {
+	var parts []string
+	if len(f.names) > 0 {
+		parts = append(parts, "name "+strings.Join(f.names, ", "))
+	}
+	if len(f.pids) > 0 {
+		parts = append(parts, "PID "+strings.Trim(fmt.Sprint(f.pids), "[]"))
+	}
+	if f.pattern != nil {
+		parts = append(parts, fmt.Sprintf("pattern %q", f.pattern))
+	}
+	return strings.Join(parts, " or ")
+}
+
+// findProcessesMatching returns the processes selected by f. ok itself and
+// its ancestors are never selected: the shell that ran `ok kill --match foo`
+// has "foo" on its own command line.
+func findProcessesMatching(f processFilter) ([]processInfo, error) {
+	table, err := process.Default().Processes()
+	if err != nil {
+		return nil, err
+	}
+	tree := process.NewTree(table)
+
+	self := os.Getpid()
+	var res []processInfo
+	for _, p := range table {
+		if p.PID == self || tree.IsAncestor(p.PID, self) || !f.matches(p) {
+			continue
+		}
+		res = append(res, processInfo{
+			PID:     p.PID,
+			Command: p.Command,
+			User:    p.User,
+			Args:    p.Args,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/antick/ok/process"
+)
+
+// expandProcessTree replaces each listener with the subtree rooted at it, or
+// at its supervising process when walkUp is set (see process.Tree.Supervisor).
+// The result is in depth-first order, parents before children, so signals
+// reach supervisors before they can respawn the processes below them.
+// Listeners keep their ports and sockets; other entries have none.
+func expandProcessTree(listeners []processInfo, walkUp bool) ([]processInfo, error) {
+	table, err := process.Default().Processes()
+	if err != nil {
+		return nil, fmt.Errorf("error reading process table: %w", err)
+	}
+	return expandTree(process.NewTree(table), listeners, walkUp, os.Getpid()), nil
+}
+
+// expandTree does the work of expandProcessTree on a given tree. self and its
+// ancestors are never part of the result, like in findProcessesMatching: when
+// ok runs from a script they share the listener's process group, and killing
+// them would take down the script and ok itself.
+func expandTree(tree *process.Tree, listeners []processInfo, walkUp bool, self int) []processInfo {
+	excluded := func(pid int) bool {
+		return pid == self || tree.IsAncestor(pid, self)
+	}
+
+	byPID := map[int]processInfo{}
+	var roots []int
+	seenRoot := map[int]bool{}
+	for _, l := range listeners {
+		byPID[l.PID] = l
+		root := l.PID
+		if walkUp {
+			root = tree.Supervisor(l.PID, self).PID
+		}
+		if !seenRoot[root] && !excluded(root) {
+			seenRoot[root] = true
+			roots = append(roots, root)
+		}
+	}
+
+	var res []processInfo
+	for _, root := range roots {
+		// A root inside another root's subtree is listed under that root
+		nested := false
+		for _, other := range roots {
+			if other != root && tree.IsAncestor(other, root) {
+				nested = true
+				break
+			}
+		}
+		if nested {
+			continue
+		}
+
+		p, ok := tree.Get(root)
+		if !ok {
+			// The process exited or is hidden from us; keep the listener as-is
+			for _, l := range listeners {
+				if l.PID == root {
+					res = append(res, l)
+				}
+			}
+			continue
+		}
+		res = append(res, processInfo{
+			PID:     p.PID,
+			Command: p.Command,
+			User:    p.User,
+			Ports:   byPID[p.PID].Ports,
+			Sockets: byPID[p.PID].Sockets,
+			Args:    p.Args,
+		})
+		kids, depths := tree.Descendants(root)
+		for i, k := range kids {
+			if excluded(k.PID) {
+				continue
+			}
+			res = append(res, processInfo{
+				PID:     k.PID,
+				Command: k.Command,
+				User:    k.User,
+				Ports:   byPID[k.PID].Ports,
+				Sockets: byPID[k.PID].Sockets,
+				Args:    k.Args,
+				Depth:   depths[i],
+			})
+		}
+	}
+	return res
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/antick/ok/process"
+)
+
+// scriptTree is a script run without job control: the script's shell, the
+// listener it started and ok all share the shell's process group.
+//
+//	1 init
+//	└─ 100 bash (group 100)
+//	   ├─ 200 lisbin (group 100, listens)
+//	   │  └─ 201 worker (group 100)
+//	   └─ 300 ok (group 100)
+//	└─ 400 npm (group 400)
+//	   └─ 401 node (group 400, listens)
+var scriptTree = process.NewTree([]process.Process{
+	{PID: 1, PPID: 0, PGID: 1, Command: "init"},
+	{PID: 100, PPID: 1, PGID: 100, Command: "bash"},
+	{PID: 200, PPID: 100, PGID: 100, Command: "lisbin"},
+	{PID: 201, PPID: 200, PGID: 100, Command: "worker"},
+	{PID: 300, PPID: 100, PGID: 100, Command: "ok"},
+	{PID: 400, PPID: 1, PGID: 400, Command: "npm"},
+	{PID: 401, PPID: 400, PGID: 400, Command: "node"},
+})
+
+const okPID = 300
+
+func expandedPIDs(listeners []int, walkUp bool) []int {
+	var procs []processInfo
+	for _, pid := range listeners {
+		procs = append(procs, processInfo{PID: pid})
+	}
+	var pids []int
+	for _, p := range expandTree(scriptTree, procs, walkUp, okPID) {
+		pids = append(pids, p.PID)
+	}
+	return pids
+}
+
+func TestExpandTreeSkipsOkAndItsShell(t *testing.T) {
+	tests := []struct {
+		name      string
+		listeners []int
+		walkUp    bool
+		want      []int
+	}{
+		{"tree of a listener in ok's group", []int{200}, false, []int{200, 201}},
+		{"parent stops below ok's shell", []int{200}, true, []int{200, 201}},
+		{"parent in a group of its own", []int{401}, true, []int{400, 401}},
+		{"listener is ok's shell", []int{100}, true, nil},
+		{"listener is ok's shell, tree only", []int{100}, false, nil},
+		{"listener is ok", []int{300}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandedPIDs(tt.listeners, tt.walkUp); !slices.Equal(got, tt.want) {
+				t.Errorf("expanded %v to %v, want %v", tt.listeners, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupervisorStopsBelowCaller(t *testing.T) {
+	if got := scriptTree.Supervisor(201, okPID).PID; got != 200 {
+		t.Errorf("supervisor of 201 is %d, want 200", got)
+	}
+	// Without a caller to protect the walk goes up to the script's shell
+	if got := scriptTree.Supervisor(201, 0).PID; got != 100 {
+		t.Errorf("supervisor of 201 with no caller is %d, want 100", got)
+	}
+}
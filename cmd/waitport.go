@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/antick/ok/process"
+)
+
+// HandleWaitPort implements `ok wait-port <port> [--free|--listening]`.
+// It exits with status 1 if the port does not reach the wanted state in time.
+func HandleWaitPort(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		color.Red("Error: No port provided")
+		cmd.Usage()
+		os.Exit(2)
+	}
+	port, err := strconv.Atoi(args[0])
+	if err != nil || port <= 0 || port > 65535 {
+		color.Red("Error: Invalid port: %s", args[0])
+		os.Exit(2)
+	}
+
+	free, _ := cmd.Flags().GetBool("free")
+	listening, _ := cmd.Flags().GetBool("listening")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	path, _ := cmd.Flags().GetString("http")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	if free && listening {
+		color.Red("Error: --free and --listening are mutually exclusive")
+		os.Exit(2)
+	}
+	query := socketQuery(cmd, []int{port})
+	if path != "" && (free || query.Proto == process.UDP) {
+		color.Red("Error: --http only makes sense when waiting for a TCP listener")
+		os.Exit(2)
+	}
+	label := portsLabel(query)
+	busy := "listening"
+	if query.Proto == process.UDP || query.AllStates {
+		busy = "in use"
+	}
+
+	var url string
+	if path != "" {
+		url = fmt.Sprintf("http://localhost:%d/%s", port, strings.TrimPrefix(path, "/"))
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	var lastErr error
+	for {
+		done, err := checkPort(query, free, url)
+		if err != nil && verbose && (lastErr == nil || err.Error() != lastErr.Error()) {
+			color.Yellow("Waiting: %v", err)
+		}
+		lastErr = err
+		if done {
+			elapsed := time.Since(start).Round(10 * time.Millisecond)
+			switch {
+			case free:
+				color.Green("%s is free (after %s)", capitalize(label), elapsed)
+			case url != "":
+				color.Green("%s is ready (after %s)", url, elapsed)
+			default:
+				color.Green("%s is %s (after %s)", capitalize(label), busy, elapsed)
+			}
+			return
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+
+	state := busy
+	if free {
+		state = "free"
+	} else if url != "" {
+		state = "ready"
+	}
+	if lastErr != nil {
+		color.Red("Timed out after %s waiting for %s to be %s: %v", timeout, label, state, lastErr)
+	} else {
+		color.Red("Timed out after %s waiting for %s to be %s", timeout, label, state)
+	}
+	os.Exit(1)
+}
+
+// checkPort reports whether the port of q is in the wanted state. The error,
+// if any, explains why it is not yet.
+func checkPort(q process.Query, free bool, url string) (bool, error) {
+	procs, err := findProcessesOnPorts(q)
+	if err != nil {
+		return false, err
+	}
+	if free {
+		switch {
+		case len(procs) == 0:
+			return true, nil
+		case procs[0].PID == 0:
+			return false, fmt.Errorf("still held by a process of %s you cannot see", procs[0].User)
+		default:
+			return false, fmt.Errorf("still held by PID %d (%s)", procs[0].PID, procs[0].Command)
+		}
+	}
+	if len(procs) == 0 {
+		return false, fmt.Errorf("nothing is using it yet")
+	}
+	if url == "" {
+		return true, nil
+	}
+
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return false, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return true, nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -19,6 +20,41 @@ type Config struct {
 	VerboseOutput bool `mapstructure:"verbose_output"`
 	// PermanentDelete sets whether to permanently delete files by default
 	PermanentDelete bool `mapstructure:"permanent_delete"`
+	// Kill configures which processes `ok kill` refuses to touch
+	Kill KillConfig `mapstructure:"kill"`
+	// Docker configures `ok docker`
+	Docker DockerConfig `mapstructure:"docker"`
+}
+
+// DockerConfig configures the Docker UI.
+type DockerConfig struct {
+	// Shell is started inside containers by the shell key
+	Shell string `mapstructure:"shell"`
+}
+
+// KillConfig configures the safety rules of `ok kill`.
+type KillConfig struct {
+	// Protected processes are only killed with --force
+	Protected ProcessRules `mapstructure:"protected"`
+	// Allowed processes are never protected, even when they match Protected
+	Allowed ProcessRules `mapstructure:"allowed"`
+}
+
+// ProcessRules matches processes by command name, owner or port.
+type ProcessRules struct {
+	Commands []string `mapstructure:"commands"`
+	// Users match processes owned by these users, unless the process is
+	// owned by whoever runs ok without sudo
+	Users []string `mapstructure:"users"`
+	Ports []int    `mapstructure:"ports"`
+}
+
+// DefaultProtectedCommands are system and Docker daemons that `ok kill`
+// refuses to touch unless the config says otherwise.
+var DefaultProtectedCommands = []string{
+	"sshd", "launchd", "systemd", "init", "kernel_task", "WindowServer", "loginwindow",
+	"dockerd", "containerd", "containerd-shim", "docker-proxy",
+	"com.docker.backend", "com.docker.vmnetd", "vpnkit",
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -30,6 +66,12 @@ func LoadConfig() (config Config, err error) {
 
 	viper.AutomaticEnv()
 
+	// Config files written by older versions have no kill section
+	viper.SetDefault("kill.protected.commands", DefaultProtectedCommands)
+	viper.SetDefault("kill.protected.users", []string{"root"})
+	viper.SetDefault("kill.protected.ports", []int{22})
+	viper.SetDefault("docker.shell", "/bin/sh")
+
 	if err = viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; ignore error if desired
@@ -51,13 +93,21 @@ func LoadConfig() (config Config, err error) {
 	return
 }
 
-func createDefaultConfig() error {
+// Dir returns the directory holding the config file and other state, ~/.ok.
+func Dir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("error getting user home directory: %w", err)
+		return "", fmt.Errorf("error getting user home directory: %w", err)
+	}
+	return filepath.Join(home, ".ok"), nil
+}
+
+func createDefaultConfig() error {
+	configDir, err := Dir()
+	if err != nil {
+		return err
 	}
 
-	configDir := filepath.Join(home, ".ok")
 	err = os.MkdirAll(configDir, 0755)
 	if err != nil {
 		return fmt.Errorf("error creating config directory: %w", err)
@@ -81,6 +131,23 @@ verbose_output: false
 
 # Permanently delete files instead of moving to trash
 permanent_delete: false
+
+# Processes 'ok kill' refuses to touch unless --force is given.
+# Users only protect processes that are not your own (e.g. root's under sudo).
+# Entries under 'allowed' override 'protected'.
+kill:
+  protected:
+    commands: [` + quoteList(DefaultProtectedCommands) + `]
+    users: [root]
+    ports: [22]
+  allowed:
+    commands: []
+    users: []
+    ports: []
+
+# Shell opened inside containers from 'ok docker'
+docker:
+  shell: "/bin/sh"
 `
 
 	_, err = f.WriteString(defaultConfig)
@@ -90,3 +157,11 @@ permanent_delete: false
 
 	return nil
 }
+
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,180 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// actionTimeout bounds every lifecycle call so a hung daemon can't freeze the UI.
+const actionTimeout = 30 * time.Second
+
+// containerAction is a lifecycle operation that can be bound to a key.
+type containerAction struct {
+	verb    string // "stop", shown in confirmations
+	running string // "Stopping", shown while the call is in flight
+	done    string // "Stopped", shown on success
+	confirm bool   // destructive actions ask first
+	run     func(ctx context.Context, ui *dockerUI, id string) error
+}
+
+var containerActions = map[rune]containerAction{
+	'S': {verb: "start", running: "Starting", done: "Started",
+		run: func(ctx context.Context, ui *dockerUI, id string) error {
+			return ui.client().ContainerStart(ctx, id, container.StartOptions{})
+		}},
+	's': {verb: "stop", running: "Stopping", done: "Stopped", confirm: true,
+		run: func(ctx context.Context, ui *dockerUI, id string) error {
+			return ui.client().ContainerStop(ctx, id, container.StopOptions{})
+		}},
+	'r': {verb: "restart", running: "Restarting", done: "Restarted", confirm: true,
+		run: func(ctx context.Context, ui *dockerUI, id string) error {
+			return ui.client().ContainerRestart(ctx, id, container.StopOptions{})
+		}},
+	'p': {verb: "pause", running: "Pausing", done: "Paused",
+		run: func(ctx context.Context, ui *dockerUI, id string) error {
+			return ui.client().ContainerPause(ctx, id)
+		}},
+	'K': {verb: "kill", running: "Killing", done: "Killed", confirm: true,
+		run: func(ctx context.Context, ui *dockerUI, id string) error {
+			return ui.client().ContainerKill(ctx, id, "SIGKILL")
+		}},
+	'D': {verb: "remove", running: "Removing", done: "Removed", confirm: true,
+		run: func(ctx context.Context, ui *dockerUI, id string) error {
+			return ui.client().ContainerRemove(ctx, id, container.RemoveOptions{Force: true})
+		}},
+}
+
+// unpauseAction replaces 'p' when the selected container is already paused.
+var unpauseAction = containerAction{verb: "unpause", running: "Unpausing", done: "Unpaused",
+	run: func(ctx context.Context, ui *dockerUI, id string) error {
+		return ui.client().ContainerUnpause(ctx, id)
+	}}
+
+// handleContainerKey dispatches the focus, grouping, shell, inspect and
+// lifecycle keys, and the project keys on Compose project rows pressed on the container table.
+func (ui *dockerUI) handleContainerKey(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyTab {
+		ui.app.SetFocus(ui.logs.view)
+		return nil
+	}
+	switch event.Rune() {
+	case ' ':
+		ui.toggleProject()
+		return nil
+	case '/':
+		ui.startSearch()
+		return nil
+	case 'a':
+		ui.toggleAll()
+		return nil
+	case '=':
+		ui.refresh()
+		return nil
+	case 'o':
+		ui.cycleSort()
+		return nil
+	case 'O':
+		if ui.sortBy != "" {
+			ui.sortDesc = !ui.sortDesc
+			ui.renderContainers()
+		}
+		return nil
+	}
+
+	row, _ := ui.containerList.GetSelection()
+	if p := ui.rowAt(row).project; p != nil {
+		if _, ok := containerActions[event.Rune()]; ok || event.Rune() == 'l' || event.Rune() == 'i' {
+			ui.handleProjectKey(*p, event.Rune())
+			return nil
+		}
+		return event
+	}
+
+	if event.Rune() == 'l' {
+		ui.openShell()
+		return nil
+	}
+	if event.Rune() == 'i' {
+		ui.showInspect()
+		return nil
+	}
+
+	action, ok := containerActions[event.Rune()]
+	if !ok {
+		return event
+	}
+
+	id, name, ok := ui.selectedContainer()
+	if !ok {
+		return nil
+	}
+	if event.Rune() == 'p' && ui.selectedPaused() {
+		action = unpauseAction
+	}
+
+	ui.requestAction(action, id, name)
+	return nil
+}
+
+// requestAction runs the action, after a confirmation for destructive ones.
+func (ui *dockerUI) requestAction(action containerAction, id, name string) {
+	if action.confirm {
+		ui.confirm(fmt.Sprintf("%s container %s?", capitalize(action.verb), name), func() {
+			ui.runAction(action, id, name)
+		})
+	} else {
+		ui.runAction(action, id, name)
+	}
+}
+
+// runAction performs the action in the background and reports the outcome in the
+// status bar, refreshing the container list once it is done.
+func (ui *dockerUI) runAction(action containerAction, id, name string) {
+	ui.setStatus("[yellow]%s %s...", action.running, name)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		err := action.run(ctx, ui, id)
+		ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ui.setStatus("[red]Failed to %s %s: %v", action.verb, name, err)
+			} else {
+				ui.setStatus("[green]%s %s", action.done, name)
+			}
+			ui.updateContainers()
+		})
+	}()
+}
+
+// confirm shows a yes/no modal over the current page and calls onYes if accepted.
+func (ui *dockerUI) confirm(question string, onYes func()) {
+	previous := ui.app.GetFocus()
+	modal := tview.NewModal().
+		SetText(question).
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(_ int, label string) {
+			ui.pages.RemovePage("confirm")
+			ui.app.SetFocus(previous)
+			if label == "Yes" {
+				onYes()
+			}
+		})
+
+	ui.pages.AddPage("confirm", modal, true, true)
+	ui.app.SetFocus(modal)
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,375 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// Table is tabular output of the non-interactive commands, with the columns
+// and formatting of the UI.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// connect opens a client for the daemon picked by the options.
+func (o Options) connect() (*client.Client, error) {
+	target, err := o.dockerContext()
+	if err != nil {
+		return nil, err
+	}
+	return newContextClient(target)
+}
+
+// ListContainers returns the containers as the UI lists them, Compose
+// projects first. Stopped containers are included when all is set.
+func ListContainers(opts Options, all bool) (Table, error) {
+	cli, err := opts.connect()
+	if err != nil {
+		return Table{}, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+	defer cancel()
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: all})
+	if err != nil {
+		return Table{}, err
+	}
+
+	table := Table{Headers: []string{"ID", "NAME", "IMAGE", "STATUS", "PROJECT"}}
+	projects, loose := groupByProject(containers)
+	for _, p := range projects {
+		for _, c := range p.Containers {
+			table.Rows = append(table.Rows, append(containerCells(c), p.Name+"/"+serviceName(c)))
+		}
+	}
+	for _, c := range loose {
+		table.Rows = append(table.Rows, append(containerCells(c), "-"))
+	}
+	return table, nil
+}
+
+// FollowLogs prints the logs of a container, or of every container of a
+// Compose project with the service in front of each line, starting with the
+// last tail lines ("all" for everything). Stderr lines go to stderr. With
+// follow it keeps printing until the containers stop.
+func FollowLogs(opts Options, name string, follow, timestamps bool, tail string) error {
+	cli, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	var targets []logTarget
+	projects, _ := groupByProject(containers)
+	for _, p := range projects {
+		if p.Name == name {
+			targets = p.logTargets()
+		}
+	}
+	if targets == nil {
+		c, err := findContainer(containers, name)
+		if err != nil {
+			return err
+		}
+		targets = []logTarget{{ID: c.ID}}
+	}
+
+	var mu sync.Mutex
+	printLine := func(line logLine) {
+		mu.Lock()
+		defer mu.Unlock()
+		out := os.Stdout
+		if line.Stderr {
+			out = os.Stderr
+		}
+		fmt.Fprintln(out, line.format(timestamps))
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets))
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target logTarget) {
+			defer wg.Done()
+			errs[i] = streamLogs(ctx, cli, target, follow, tail, printLine)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StopContainers stops the running containers matching pattern: a glob such
+// as "shop-*" matched against names, or else a name, unique name prefix or ID
+// prefix. done is called as each one stops or fails. A zero timeout leaves the
+// grace period to the container's configuration.
+func StopContainers(opts Options, pattern string, timeout time.Duration, done func(name string, err error)) error {
+	cli, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	var matched []types.Container
+	if strings.ContainsAny(pattern, "*?[") {
+		for _, c := range containers {
+			if ok, err := path.Match(pattern, containerName(c)); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			} else if ok {
+				matched = append(matched, c)
+			}
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("no running container matches %q", pattern)
+		}
+	} else {
+		c, err := findContainer(containers, pattern)
+		if err != nil {
+			return err
+		}
+		matched = []types.Container{c}
+	}
+
+	var stopOpts container.StopOptions
+	if timeout > 0 {
+		secs := int(timeout.Seconds())
+		stopOpts.Timeout = &secs
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, c := range matched {
+		wg.Add(1)
+		go func(c types.Container) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout+actionTimeout)
+			defer cancel()
+
+			err := cli.ContainerStop(ctx, c.ID, stopOpts)
+			mu.Lock()
+			defer mu.Unlock()
+			done(containerName(c), err)
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+// PruneGroup is what a prune removes of one kind of object.
+type PruneGroup struct {
+	What string // e.g. "Dangling images"
+	Table
+}
+
+// PruneCandidates lists what Prune would remove: stopped containers, dangling
+// images no container uses, custom networks without containers and, with
+// volumes, anonymous volumes no container mounts. The daemon decides for
+// itself when pruning, so this is a close preview rather than a guarantee.
+func PruneCandidates(opts Options, volumes bool) ([]PruneGroup, error) {
+	cli, err := opts.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+	defer cancel()
+
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	stopped := PruneGroup{What: "Stopped containers", Table: Table{Headers: []string{"ID", "NAME", "IMAGE", "STATUS"}}}
+	for _, c := range containers {
+		if c.State == "exited" || c.State == "created" || c.State == "dead" {
+			stopped.Rows = append(stopped.Rows, containerCells(c))
+		}
+	}
+
+	images, err := cli.ImageList(ctx, image.ListOptions{ContainerCount: true, Filters: filters.NewArgs(filters.Arg("dangling", "true"))})
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	for _, c := range containers {
+		used[c.ImageID] = true
+	}
+	dangling := PruneGroup{What: "Dangling images", Table: Table{Headers: []string{"ID", "SIZE", "CREATED"}}}
+	for _, img := range images {
+		if img.Containers <= 0 && !used[img.ID] {
+			dangling.Rows = append(dangling.Rows, []string{shortID(strings.TrimPrefix(img.ID, "sha256:")),
+				formatBytes(float64(img.Size)), since(time.Unix(img.Created, 0))})
+		}
+	}
+
+	networks, err := cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	attached := make(map[string]bool)
+	for _, c := range containers {
+		if c.NetworkSettings != nil {
+			for _, endpoint := range c.NetworkSettings.Networks {
+				if endpoint != nil {
+					attached[endpoint.NetworkID] = true
+				}
+			}
+		}
+	}
+	unusedNetworks := PruneGroup{What: "Unused networks", Table: Table{Headers: []string{"ID", "NAME", "DRIVER"}}}
+	for _, n := range networks {
+		if !attached[n.ID] && !predefinedNetworks[n.Name] {
+			unusedNetworks.Rows = append(unusedNetworks.Rows, []string{shortID(n.ID), n.Name, n.Driver})
+		}
+	}
+	sort.Slice(unusedNetworks.Rows, func(i, j int) bool { return unusedNetworks.Rows[i][1] < unusedNetworks.Rows[j][1] })
+
+	groups := []PruneGroup{stopped, dangling, unusedNetworks}
+	if !volumes {
+		return groups, nil
+	}
+
+	resp, err := cli.VolumeList(ctx, volume.ListOptions{Filters: filters.NewArgs(filters.Arg("dangling", "true"))})
+	if err != nil {
+		return nil, err
+	}
+	anonymous := PruneGroup{What: "Unused anonymous volumes", Table: Table{Headers: []string{"NAME", "DRIVER"}}}
+	for _, v := range resp.Volumes {
+		if _, ok := v.Labels[anonymousVolumeLabel]; ok {
+			anonymous.Rows = append(anonymous.Rows, []string{v.Name, v.Driver})
+		}
+	}
+	return append(groups, anonymous), nil
+}
+
+// predefinedNetworks are created by the daemon and never pruned.
+var predefinedNetworks = map[string]bool{"bridge": true, "host": true, "none": true}
+
+// anonymousVolumeLabel marks volumes created without a name, the only ones
+// pruned by default since API 1.42.
+const anonymousVolumeLabel = "com.docker.volume.anonymous"
+
+// Prune removes stopped containers, dangling images, unused networks and,
+// with volumes, unused anonymous volumes, describing what each step freed.
+func Prune(opts Options, volumes bool) ([]string, error) {
+	cli, err := opts.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), composeTimeout)
+	defer cancel()
+
+	// Containers go first so that what they used becomes unused
+	steps := []func(context.Context, dockerService) (string, error){pruneContainers, pruneImages, pruneNetworks}
+	if volumes {
+		steps = append(steps, pruneVolumes)
+	}
+	var results []string
+	for _, step := range steps {
+		result, err := step(ctx, cli)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Stats shows the live stats of the running containers through show, once
+// per second, or once when stream is false.
+func Stats(opts Options, stream bool, show func(Table)) error {
+	cli, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	collector := newStatsCollector(cli)
+	defer collector.watch(nil)
+
+	ticker := time.NewTicker(statsRedrawInterval)
+	defer ticker.Stop()
+	for waited := 0; ; waited++ {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		containers, err := cli.ContainerList(ctx, container.ListOptions{})
+		cancel()
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, c := range containers {
+			ids = append(ids, c.ID)
+		}
+		collector.watch(ids)
+
+		table, complete := statsTable(collector, containers)
+		// Rates need two samples, so a single report waits for them
+		if stream || complete || waited >= 5 {
+			show(table)
+			if !stream {
+				return nil
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// statsTable renders the latest stats of the containers, and reports whether
+// every one of them has had at least two samples.
+func statsTable(collector *statsCollector, containers []types.Container) (Table, bool) {
+	table := Table{Headers: []string{"ID", "NAME", "CPU", "MEM", "LIMIT", "MEM %", "NET RX/TX", "BLOCK R/W", "PIDS"}}
+	complete := true
+	for _, c := range containers {
+		samples, _ := collector.history(c.ID)
+		complete = complete && len(samples) >= 2
+		if len(samples) == 0 {
+			table.Rows = append(table.Rows, []string{shortID(c.ID), containerName(c), "-", "-", "-", "-", "-", "-", "-"})
+			continue
+		}
+		s := samples[len(samples)-1]
+		table.Rows = append(table.Rows, []string{
+			shortID(c.ID), containerName(c), s.cpuText(),
+			formatBytes(float64(s.MemUsage)), formatBytes(float64(s.MemLimit)), fmt.Sprintf("%.1f%%", s.MemPercent()),
+			formatBytes(s.NetRx) + "/s / " + formatBytes(s.NetTx) + "/s",
+			formatBytes(s.BlockRead) + "/s / " + formatBytes(s.BlockWrite) + "/s",
+			fmt.Sprint(s.PIDs),
+		})
+	}
+	return table, complete
+}
@@ -0,0 +1,15 @@
+package docker
+
+import (
+	"github.com/docker/docker/client"
+)
+
+// newClient connects to the daemon the docker CLI would use: DOCKER_HOST, or
+// the current Docker context, or the local socket.
+func newClient() (*client.Client, error) {
+	ctx, err := currentContext()
+	if err != nil {
+		return nil, err
+	}
+	return newContextClient(ctx)
+}
@@ -0,0 +1,272 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/gdamore/tcell/v2"
+)
+
+// command is something that can be typed after ':'.
+type command struct {
+	args string // usage of the argument, empty when it takes none
+	help string
+	// complete returns the candidates for the argument
+	complete func(ui *dockerUI) []string
+	run      func(ui *dockerUI, arg string)
+}
+
+var commands = map[string]command{
+	"q":    {help: "quit", run: func(ui *dockerUI, _ string) { ui.app.Stop() }},
+	"quit": {help: "quit", run: func(ui *dockerUI, _ string) { ui.app.Stop() }},
+	"containers": {help: "show the containers",
+		run: func(ui *dockerUI, _ string) { ui.showContainers() }},
+	"filter": {args: "[text]", help: "show containers whose name, image, ID or project contain text; no text clears it",
+		run: func(ui *dockerUI, arg string) {
+			ui.showContainers()
+			ui.search.SetText(arg)
+			if arg == "" {
+				ui.containerPanel.ResizeItem(ui.search, 0, 0)
+			} else {
+				ui.containerPanel.ResizeItem(ui.search, 1, 0)
+			}
+		}},
+	"sort": {args: "[" + strings.Join(sortOrder, "|") + "]", help: "sort the containers; no column restores the default order",
+		complete: func(*dockerUI) []string { return sortOrder },
+		run: func(ui *dockerUI, arg string) {
+			if arg != "" && !slices.Contains(sortOrder, arg) {
+				ui.setStatus("[red]Can't sort by %s, use one of %s", arg, strings.Join(sortOrder, ", "))
+				return
+			}
+			ui.sortBy, ui.sortDesc = arg, false
+			ui.showContainers()
+			ui.renderContainers()
+		}},
+	"start":   {args: "<container>", help: "start a container", complete: containerNames, run: commandAction('S')},
+	"stop":    {args: "<container>", help: "stop a container", complete: containerNames, run: commandAction('s')},
+	"restart": {args: "<container>", help: "restart a container", complete: containerNames, run: commandAction('r')},
+	"logs": {args: "<container|project>", help: "follow logs", complete: logNames,
+		run: func(ui *dockerUI, arg string) {
+			for _, p := range ui.projects() {
+				if p.Name == arg {
+					ui.logs.follow(p.Name, p.logTargets()...)
+					return
+				}
+			}
+			c, err := ui.findContainer(arg)
+			if err != nil {
+				ui.setStatus("[red]%v", err)
+				return
+			}
+			ui.logs.follow(containerName(c), logTarget{ID: c.ID})
+		}},
+	"ctx": {args: "[context|host]", help: "switch Docker context or connect to a host; no argument picks from a list", complete: contextNames,
+		run: func(ui *dockerUI, arg string) {
+			if arg == "" {
+				ui.showContextPicker()
+				return
+			}
+			ui.switchContext(arg)
+		}},
+	"help": {help: "list the commands"},
+}
+
+func init() {
+	for name, kind := range resourceKinds {
+		kind := kind
+		commands[name] = command{help: "show the " + name, run: func(ui *dockerUI, _ string) { ui.showResources(kind) }}
+	}
+}
+
+// runCommand executes a line typed after ':'.
+func (ui *dockerUI) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	if name == "" {
+		return
+	}
+	if name == "help" {
+		var usage []string
+		for _, name := range sortedKeys(commands) {
+			usage = append(usage, strings.TrimSpace(name+" "+commands[name].args))
+		}
+		ui.setStatus("Commands: %s", strings.Join(usage, ", "))
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		ui.setStatus("[red]Unknown command: %s (try :help)", name)
+		return
+	}
+	if strings.HasPrefix(cmd.args, "<") && arg == "" {
+		ui.setStatus("[red]Usage: :%s %s", name, cmd.args)
+		return
+	}
+	cmd.run(ui, arg)
+}
+
+// handleCommandKey browses the history with up/down and completes with tab.
+func (ui *dockerUI) handleCommandKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyUp:
+		if ui.historyPos > 0 {
+			ui.historyPos--
+			ui.commandInput.SetText(ui.history[ui.historyPos])
+		}
+	case tcell.KeyDown:
+		if ui.historyPos < len(ui.history) {
+			ui.historyPos++
+		}
+		if ui.historyPos < len(ui.history) {
+			ui.commandInput.SetText(ui.history[ui.historyPos])
+		} else {
+			ui.commandInput.SetText("")
+		}
+	case tcell.KeyTab:
+		ui.complete()
+	default:
+		return event
+	}
+	return nil
+}
+
+// complete extends the command or its argument to the longest common prefix of
+// the candidates, listing them in the status bar when there are several.
+func (ui *dockerUI) complete() {
+	text := ui.commandInput.GetText()
+	name, arg, hasArg := strings.Cut(text, " ")
+
+	var candidates []string
+	if hasArg {
+		if cmd, ok := commands[name]; ok && cmd.complete != nil {
+			candidates = cmd.complete(ui)
+		}
+	} else {
+		candidates, arg = sortedKeys(commands), name
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, arg) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		completed := matches[0] + " "
+		if hasArg {
+			completed = name + " " + matches[0]
+		}
+		ui.commandInput.SetText(completed)
+		return
+	}
+
+	prefix := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if hasArg {
+		prefix = name + " " + prefix
+	}
+	ui.commandInput.SetText(prefix)
+	ui.setStatus("%s", strings.Join(matches, "  "))
+}
+
+// commandAction runs a container action on the named container, asking first
+// when the key would.
+func commandAction(key rune) func(ui *dockerUI, arg string) {
+	return func(ui *dockerUI, arg string) {
+		c, err := ui.findContainer(arg)
+		if err != nil {
+			ui.setStatus("[red]%v", err)
+			return
+		}
+		action := containerActions[key]
+		if key == 'S' && c.State == "paused" {
+			action = unpauseAction
+		}
+		ui.requestAction(action, c.ID, containerName(c))
+	}
+}
+
+// findContainer resolves a name typed in a command.
+func (ui *dockerUI) findContainer(name string) (types.Container, error) {
+	return findContainer(ui.containers, name)
+}
+
+// findContainer resolves a container name given by the user: an exact name,
+// then a unique name prefix, then a unique ID prefix. A prefix matching several
+// containers is an error listing them rather than a guess.
+func findContainer(containers []types.Container, name string) (types.Container, error) {
+	if strings.TrimSpace(name) == "" {
+		return types.Container{}, errors.New("no container name given")
+	}
+	var byName, byID []types.Container
+	for _, c := range containers {
+		if containerName(c) == name {
+			return c, nil
+		}
+		if strings.HasPrefix(containerName(c), name) {
+			byName = append(byName, c)
+		}
+		if strings.HasPrefix(c.ID, name) {
+			byID = append(byID, c)
+		}
+	}
+	for _, matched := range [][]types.Container{byName, byID} {
+		switch len(matched) {
+		case 0:
+			continue
+		case 1:
+			return matched[0], nil
+		}
+		candidates := make([]string, len(matched))
+		for i, c := range matched {
+			candidates[i] = fmt.Sprintf("%s (%s)", containerName(c), shortID(c.ID))
+		}
+		return types.Container{}, fmt.Errorf("%q matches %d containers: %s", name, len(matched), strings.Join(candidates, ", "))
+	}
+	return types.Container{}, fmt.Errorf("no container named %q", name)
+}
+
+// projects returns the Compose projects of the latest listing.
+func (ui *dockerUI) projects() []composeProject {
+	projects, _ := groupByProject(ui.containers)
+	return projects
+}
+
+func containerNames(ui *dockerUI) []string {
+	var names []string
+	for _, c := range ui.containers {
+		names = append(names, containerName(c))
+	}
+	return names
+}
+
+func logNames(ui *dockerUI) []string {
+	names := containerNames(ui)
+	for _, p := range ui.projects() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func contextNames(*dockerUI) []string {
+	contexts, _ := listContexts()
+	var names []string
+	for _, ctx := range contexts {
+		names = append(names, ctx.Name)
+	}
+	return names
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestFindContainer(t *testing.T) {
+	containers := []types.Container{
+		fakeContainer("aaaa00000001", "shop-web-1", "nginx:1.27", "running"),
+		fakeContainer("aaaa00000002", "shop-db-1", "postgres:16", "running"),
+		fakeContainer("bbbb00000001", "shop", "alpine:3", "running"),
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    string // container name, empty when an error is expected
+		err     string
+	}{
+		{"exact name beats a prefix", "shop", "shop", ""},
+		{"unique name prefix", "shop-w", "shop-web-1", ""},
+		{"ambiguous name prefix", "shop-", "", `"shop-" matches 2 containers: shop-web-1 (aaaa00000001), shop-db-1 (aaaa00000002)`},
+		{"unique ID prefix", "bbbb", "shop", ""},
+		{"ambiguous ID prefix", "aaaa", "", `"aaaa" matches 2 containers: shop-web-1 (aaaa00000001), shop-db-1 (aaaa00000002)`},
+		{"empty pattern", "", "", "no container name given"},
+		{"blank pattern", "  ", "", "no container name given"},
+		{"no match", "cache", "", `no container named "cache"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := findContainer(containers, tt.pattern)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("findContainer(%q) = %s, %v, want error %q", tt.pattern, containerName(c), err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findContainer(%q): %v", tt.pattern, err)
+			}
+			if got := containerName(c); got != tt.want {
+				t.Errorf("findContainer(%q) = %s, want %s", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Labels Docker Compose puts on the containers it creates.
+const (
+	composeProjectLabel     = "com.docker.compose.project"
+	composeServiceLabel     = "com.docker.compose.service"
+	composeWorkingDirLabel  = "com.docker.compose.project.working_dir"
+	composeConfigFilesLabel = "com.docker.compose.project.config_files"
+)
+
+// composeTimeout bounds project commands, which may pull or build images.
+const composeTimeout = 10 * time.Minute
+
+// serviceColors tell services apart in aggregated project logs.
+var serviceColors = []string{"aqua", "green", "yellow", "fuchsia", "orange", "lime", "violet", "teal"}
+
+// composeProject is a Compose project and its containers.
+type composeProject struct {
+	Name       string
+	Containers []types.Container
+}
+
+// groupByProject splits containers into Compose projects, sorted by name,
+// and the containers that belong to none.
+func groupByProject(containers []types.Container) (projects []composeProject, loose []types.Container) {
+	index := make(map[string]int)
+	for _, c := range containers {
+		name := c.Labels[composeProjectLabel]
+		if name == "" {
+			loose = append(loose, c)
+			continue
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(projects)
+			index[name] = i
+			projects = append(projects, composeProject{Name: name})
+		}
+		projects[i].Containers = append(projects[i].Containers, c)
+	}
+
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	for _, p := range projects {
+		sort.Slice(p.Containers, func(i, j int) bool { return serviceName(p.Containers[i]) < serviceName(p.Containers[j]) })
+	}
+	return projects, loose
+}
+
+// running counts the project's running containers.
+func (p composeProject) running() int {
+	n := 0
+	for _, c := range p.Containers {
+		if c.State == "running" {
+			n++
+		}
+	}
+	return n
+}
+
+// services returns the project's service names in order, without duplicates
+// from scaled services.
+func (p composeProject) services() []string {
+	var services []string
+	seen := make(map[string]bool)
+	for _, c := range p.Containers {
+		if name := serviceName(c); !seen[name] {
+			seen[name] = true
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
+// logTargets follows every container of the project, coloured by service.
+func (p composeProject) logTargets() []logTarget {
+	colors := make(map[string]string)
+	for i, service := range p.services() {
+		colors[service] = serviceColors[i%len(serviceColors)]
+	}
+
+	var targets []logTarget
+	width := 0
+	for _, c := range p.Containers {
+		prefix := serviceName(c)
+		if len(p.Containers) > len(p.services()) {
+			prefix = containerName(c) // scaled services: tell the replicas apart
+		}
+		width = max(width, len(prefix))
+		targets = append(targets, logTarget{ID: c.ID, Prefix: prefix, Color: colors[serviceName(c)]})
+	}
+	for i := range targets {
+		targets[i].Prefix = fmt.Sprintf("%-*s", width, targets[i].Prefix)
+	}
+	return targets
+}
+
+// serviceName is the Compose service of a container, or its name outside Compose.
+func serviceName(c types.Container) string {
+	if service := c.Labels[composeServiceLabel]; service != "" {
+		return service
+	}
+	return containerName(c)
+}
+
+// compose runs a `docker compose` command for the project, from its working
+// directory and with its config files, against the daemon the UI talks to.
+func (ui *dockerUI) compose(ctx context.Context, p composeProject, args ...string) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("the docker CLI with the compose plugin is required")
+	}
+
+	// Target the daemon the UI talks to
+	var cmdArgs []string
+	env := os.Environ()
+	if target := ui.connectedTo(); target.Stored {
+		cmdArgs = append(cmdArgs, "--context", target.Name)
+	} else {
+		env = append(env, "DOCKER_HOST="+target.Host)
+	}
+	cmdArgs = append(cmdArgs, "compose", "--project-name", p.Name)
+	var dir string
+	for _, c := range p.Containers {
+		if dir == "" {
+			dir = c.Labels[composeWorkingDirLabel]
+		}
+		if files := c.Labels[composeConfigFilesLabel]; files != "" {
+			for _, file := range strings.Split(files, ",") {
+				cmdArgs = append(cmdArgs, "--file", file)
+			}
+			break
+		}
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := exec.CommandContext(ctx, "docker", cmdArgs...)
+	cmd.Dir = dir
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+	}
+	return nil
+}
+
+// projectAction is a Compose project operation bound to the same key as the
+// matching container action.
+type projectAction struct {
+	verb    string
+	running string
+	done    string
+	confirm bool
+	run     func(ctx context.Context, ui *dockerUI, p composeProject) error
+}
+
+var projectActions = map[rune]projectAction{
+	'S': {verb: "up", running: "Starting", done: "Started",
+		run: func(ctx context.Context, ui *dockerUI, p composeProject) error {
+			return ui.compose(ctx, p, "up", "--detach")
+		}},
+	's': {verb: "down", running: "Taking down", done: "Took down", confirm: true,
+		run: func(ctx context.Context, ui *dockerUI, p composeProject) error {
+			return ui.compose(ctx, p, "down")
+		}},
+	'r': {verb: "restart", running: "Restarting", done: "Restarted", confirm: true,
+		run: restartProject},
+}
+
+// restartProject restarts all containers of the project at once through the API.
+func restartProject(ctx context.Context, ui *dockerUI, p composeProject) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(p.Containers))
+	for i, c := range p.Containers {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = ui.client().ContainerRestart(ctx, id, container.StopOptions{})
+		}(i, c.ID)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleProjectKey runs the project-level version of a container key.
+func (ui *dockerUI) handleProjectKey(p composeProject, key rune) {
+	action, ok := projectActions[key]
+	if !ok {
+		ui.setStatus("[yellow]Select a container of %s for that, or use S up, s down, r restart", p.Name)
+		return
+	}
+
+	run := func() {
+		ui.setStatus("[yellow]%s project %s...", action.running, p.Name)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), composeTimeout)
+			defer cancel()
+
+			err := action.run(ctx, ui, p)
+			ui.app.QueueUpdateDraw(func() {
+				if err != nil {
+					ui.setStatus("[red]Failed to %s %s: %v", action.verb, p.Name, err)
+				} else {
+					ui.setStatus("[green]%s project %s", action.done, p.Name)
+				}
+				ui.updateContainers()
+			})
+		}()
+	}
+
+	if action.confirm {
+		ui.confirm(fmt.Sprintf("%s project %s (%s)?", capitalize(action.verb), p.Name, strings.Join(p.services(), ", ")), run)
+	} else {
+		run()
+	}
+}
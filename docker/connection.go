@@ -0,0 +1,204 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// reconnectInterval is how often the daemon is pinged, which is also how
+	// soon the UI notices it going away or coming back.
+	reconnectInterval = 3 * time.Second
+	// pingTimeout is shorter than actionTimeout: a daemon that doesn't answer a
+	// ping within it counts as unavailable.
+	pingTimeout = 5 * time.Second
+)
+
+// connectionState is what the last ping of the daemon found.
+type connectionState int
+
+const (
+	connecting connectionState = iota
+	connected
+	disconnected
+)
+
+// monitorConnection pings the daemon until the UI exits, and on demand when a
+// request fails, showing the error screen while it's unreachable.
+func (ui *dockerUI) monitorConnection() {
+	ticker := time.NewTicker(reconnectInterval)
+	defer ticker.Stop()
+	for {
+		cli := ui.client()
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		ping, err := cli.Ping(ctx)
+		cancel()
+
+		ui.app.QueueUpdateDraw(func() {
+			// A context switch happened meanwhile
+			if cli != ui.client() {
+				return
+			}
+			ui.setConnection(ping.APIVersion, err)
+		})
+
+		select {
+		case <-ui.done:
+			return
+		case <-ticker.C:
+		case <-ui.checkConnection:
+		}
+	}
+}
+
+// recheckConnection pings the daemon now rather than at the next tick. It
+// doesn't block, so it's safe on the UI goroutine.
+func (ui *dockerUI) recheckConnection() {
+	select {
+	case ui.checkConnection <- struct{}{}:
+	default: // a check is already pending
+	}
+}
+
+// setConnection records the outcome of a ping, with the API version the daemon
+// reported, showing or dismissing the error screen and catching up on
+// everything missed after an outage.
+func (ui *dockerUI) setConnection(apiVersion string, err error) {
+	previous := ui.connState
+	ui.lastCheck = time.Now()
+	if err != nil {
+		if previous != disconnected {
+			ui.downSince = ui.lastCheck
+		}
+		ui.connState, ui.connErr = disconnected, err
+		ui.showUnavailable()
+		ui.renderConnection()
+		return
+	}
+
+	ui.connState, ui.connErr, ui.apiVersion = connected, nil, apiVersion
+	ui.renderConnection()
+	if previous != disconnected {
+		return
+	}
+	ui.hideUnavailable()
+	// The event stream broke too; resubscribing now beats waiting for its retry
+	ui.watchEvents()
+	ui.setStatus("[green]Reconnected to %s after %s", describeContext(ui.connectedTo()), time.Since(ui.downSince).Round(time.Second))
+}
+
+// renderConnection shows the connection state and API version at the right of
+// the status bar.
+func (ui *dockerUI) renderConnection() {
+	name := tview.Escape(ui.connectedTo().Name)
+	var text string
+	switch ui.connState {
+	case connecting:
+		text = fmt.Sprintf("[yellow]◌ connecting to %s", name)
+	case connected:
+		text = fmt.Sprintf("[green]●[-] %s · API %s", name, tview.Escape(ui.apiVersion))
+	case disconnected:
+		text = fmt.Sprintf("[red]● %s unavailable", name)
+	}
+	ui.connectionView.SetText(text)
+	ui.statusRow.ResizeItem(ui.connectionView, tview.TaggedStringWidth(text)+1, 0)
+}
+
+// showUnavailable puts the error screen over the dashboard, or refreshes it
+// when it's already there. It doesn't interrupt a dialog or the command input.
+func (ui *dockerUI) showUnavailable() {
+	target := ui.connectedTo()
+	cause, hint := explainConnectionError(ui.connErr, target)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[red::b]%s[-::-]\n\n", tview.Escape(cause))
+	if hint != "" {
+		fmt.Fprintf(&b, "%s\n\n", tview.Escape(hint))
+	}
+	fmt.Fprintf(&b, "[gray]Error: %s[-]\n\n", tview.Escape(ui.connErr.Error()))
+	fmt.Fprintf(&b, "Reconnecting every %s — unavailable for %s, last tried at %s.\n\n",
+		reconnectInterval, time.Since(ui.downSince).Round(time.Second), ui.lastCheck.Format("15:04:05"))
+	b.WriteString("[::b]r[::-]:retry now  [::b]:ctx[::-]:switch context  [::b]q[::-]:quit")
+	ui.unavailableView.SetText(b.String())
+	ui.unavailableView.SetTitle(" Docker unavailable — " + tview.Escape(describeContext(target)) + " ")
+
+	if slices.Contains(ui.pages.GetPageNames(true), "unavailable") {
+		return
+	}
+	switch page, _ := ui.pages.GetFrontPage(); page {
+	case "main", "resources", "inspect":
+	default:
+		return // shown at the next check
+	}
+	ui.focusBeforeError = ui.app.GetFocus()
+	ui.pages.AddPage("unavailable", ui.unavailableOverlay, true, true)
+	// The command input stays on top, so :ctx works from the error screen
+	ui.pages.AddPage("input", ui.inputOverlay, true, false)
+	ui.app.SetFocus(ui.unavailableView)
+}
+
+// hideUnavailable removes the error screen and gives the focus back.
+func (ui *dockerUI) hideUnavailable() {
+	if !ui.pages.HasPage("unavailable") {
+		return
+	}
+	ui.pages.RemovePage("unavailable")
+	if ui.app.GetFocus() == ui.unavailableView {
+		ui.app.SetFocus(ui.focusBeforeError)
+	}
+}
+
+// newUnavailableScreen builds the error screen shown while the daemon is unreachable.
+func (ui *dockerUI) newUnavailableScreen() {
+	ui.unavailableView = tview.NewTextView().SetDynamicColors(true).SetWordWrap(true)
+	ui.unavailableView.SetBorder(true).SetBorderColor(tcell.ColorRed).SetBorderPadding(1, 1, 2, 2)
+	ui.unavailableView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'r':
+			ui.unavailableView.SetTitle(" Docker unavailable — retrying... ")
+			ui.recheckConnection()
+		case 'q':
+			ui.app.Stop()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	ui.unavailableOverlay = tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(ui.unavailableView, 16, 0, true).
+			AddItem(nil, 0, 1, false), 0, 3, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// explainConnectionError turns a failed ping into a cause and a hint at the fix.
+func explainConnectionError(err error, target dockerContext) (cause, hint string) {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(target.Host, "ssh://"):
+		return "Cannot reach the Docker daemon of " + target.Host + " over SSH.",
+			"Check that `ssh` to the host works without a password prompt (use a key or an agent) and that docker runs there."
+	case strings.Contains(message, "permission denied"):
+		return "Permission denied on the Docker socket " + target.Host + ".",
+			"Add your user to the docker group (sudo usermod -aG docker $USER) and log in again, or run a rootless daemon."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "The Docker daemon at " + target.Host + " doesn't answer.",
+			"It may be starting up or overloaded."
+	case client.IsErrConnectionFailed(err), strings.Contains(message, "connection refused"), strings.Contains(message, "no such file"):
+		return "Cannot connect to the Docker daemon at " + target.Host + ".",
+			"Is it running? Start Docker Desktop, or run: sudo systemctl start docker"
+	default:
+		return "Cannot talk to the Docker daemon at " + target.Host + ".", ""
+	}
+}
@@ -0,0 +1,166 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// defaultContext is the context that uses DOCKER_HOST or the local socket.
+const defaultContext = "default"
+
+// dockerContext is an endpoint from the Docker CLI's context store.
+type dockerContext struct {
+	Name        string
+	Description string
+	Host        string
+	// TLSDir holds ca.pem, cert.pem and key.pem when the context uses TLS
+	TLSDir string
+	// Stored is false for the default context and hosts given with --host
+	Stored bool
+}
+
+// contextMeta is the meta.json of a stored context.
+type contextMeta struct {
+	Name     string `json:"Name"`
+	Metadata struct {
+		Description string `json:"Description"`
+	} `json:"Metadata"`
+	Endpoints map[string]struct {
+		Host string `json:"Host"`
+	} `json:"Endpoints"`
+}
+
+// dockerConfigDir is where the Docker CLI keeps its config and contexts.
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".docker"
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// listContexts returns the default context followed by the stored ones by name.
+func listContexts() ([]dockerContext, error) {
+	host := os.Getenv(client.EnvOverrideHost)
+	if host == "" {
+		host = client.DefaultDockerHost
+	}
+	contexts := []dockerContext{{Name: defaultContext, Description: "DOCKER_HOST or the local socket", Host: host}}
+
+	metaDir := filepath.Join(dockerConfigDir(), "contexts", "meta")
+	entries, err := os.ReadDir(metaDir)
+	if os.IsNotExist(err) {
+		return contexts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading Docker contexts: %w", err)
+	}
+
+	var stored []dockerContext
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(metaDir, entry.Name(), "meta.json"))
+		if err != nil {
+			continue
+		}
+		var meta contextMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Name == "" {
+			continue
+		}
+
+		ctx := dockerContext{
+			Name:        meta.Name,
+			Description: meta.Metadata.Description,
+			Host:        meta.Endpoints["docker"].Host,
+			Stored:      true,
+		}
+		tlsDir := filepath.Join(dockerConfigDir(), "contexts", "tls", entry.Name(), "docker")
+		if _, err := os.Stat(filepath.Join(tlsDir, "ca.pem")); err == nil {
+			ctx.TLSDir = tlsDir
+		}
+		stored = append(stored, ctx)
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Name < stored[j].Name })
+	return append(contexts, stored...), nil
+}
+
+// findContext looks a context up by name.
+func findContext(name string) (dockerContext, error) {
+	contexts, err := listContexts()
+	if err != nil {
+		return dockerContext{}, err
+	}
+	for _, ctx := range contexts {
+		if ctx.Name == name {
+			return ctx, nil
+		}
+	}
+	return dockerContext{}, fmt.Errorf("no Docker context named %q", name)
+}
+
+// currentContext is the context the docker CLI would use: the default one when
+// DOCKER_HOST is set, else DOCKER_CONTEXT, else the one chosen with
+// `docker context use`.
+func currentContext() (dockerContext, error) {
+	if os.Getenv(client.EnvOverrideHost) != "" {
+		return findContext(defaultContext)
+	}
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return findContext(name)
+	}
+
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	data, err := os.ReadFile(filepath.Join(dockerConfigDir(), "config.json"))
+	if err == nil {
+		json.Unmarshal(data, &config)
+	}
+	if config.CurrentContext == "" {
+		return findContext(defaultContext)
+	}
+	return findContext(config.CurrentContext)
+}
+
+// hostContext is an unnamed context for a host given on the command line.
+func hostContext(host string) dockerContext {
+	return dockerContext{Name: host, Host: host}
+}
+
+// newContextClient connects to the endpoint of a context. ssh:// hosts are
+// reached through ssh; the default context also takes its TLS settings from
+// the DOCKER_* environment.
+func newContextClient(ctx dockerContext) (*client.Client, error) {
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+	if ctx.Name == defaultContext {
+		opts = append(opts, client.WithTLSClientConfigFromEnv(), client.WithVersionFromEnv())
+	}
+
+	if strings.HasPrefix(ctx.Host, "ssh://") {
+		dial, err := sshDialer(ctx.Host)
+		if err != nil {
+			return nil, err
+		}
+		// The host only names the daemon in requests, the dialer does the connecting
+		opts = append(opts, client.WithHost("http://docker.example.com"), client.WithDialContext(dial))
+	} else {
+		opts = append(opts, client.WithHost(ctx.Host))
+	}
+
+	if ctx.TLSDir != "" {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(ctx.TLSDir, "ca.pem"),
+			filepath.Join(ctx.TLSDir, "cert.pem"),
+			filepath.Join(ctx.TLSDir, "key.pem"),
+		))
+	}
+	return client.NewClientWithOpts(opts...)
+}
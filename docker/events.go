@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	// eventsRetryInterval is the wait before resubscribing when the event stream breaks.
+	eventsRetryInterval = 5 * time.Second
+	// resyncInterval relists everything now and then, which keeps relative times
+	// like "Up 5 minutes" current and catches anything the events missed.
+	resyncInterval = time.Minute
+)
+
+// listedEvents are the container events that change what the table shows.
+// Exec, attach and resize events are frequent and change nothing.
+var listedEvents = map[events.Action]bool{
+	events.ActionCreate:  true,
+	events.ActionStart:   true,
+	events.ActionRestart: true,
+	events.ActionStop:    true,
+	events.ActionDie:     true,
+	events.ActionKill:    true,
+	events.ActionOOM:     true,
+	events.ActionPause:   true,
+	events.ActionUnPause: true,
+	events.ActionRename:  true,
+	events.ActionUpdate:  true,
+	events.ActionDestroy: true,
+}
+
+// watchEvents keeps the container list in sync with the daemon's events,
+// replacing the subscription of a previous client.
+func (ui *dockerUI) watchEvents() {
+	if ui.stopEvents != nil {
+		ui.stopEvents()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ui.stopEvents = cancel
+	go ui.followEvents(ctx, ui.client())
+}
+
+func (ui *dockerUI) followEvents(ctx context.Context, cli dockerService) {
+	for {
+		// Subscribe before listing so nothing that happens in between is missed
+		messages, errs := cli.Events(ctx, events.ListOptions{
+			Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType))),
+		})
+		// updateContainers draws once the listing arrives
+		ui.app.QueueUpdate(ui.updateContainers)
+		resync := time.NewTicker(resyncInterval)
+
+	stream:
+		for {
+			select {
+			case msg := <-messages:
+				id, action := msg.Actor.ID, msg.Action
+				if listedEvents[action] || strings.HasPrefix(string(action), string(events.ActionHealthStatus)) {
+					// A destroy removes the row right away, which needs a draw
+					ui.app.QueueUpdateDraw(func() { ui.updateContainer(id, action) })
+				}
+			case <-resync.C:
+				ui.app.QueueUpdate(ui.updateContainers)
+			case <-errs:
+				// The daemon may be gone; the error screen says so sooner than the retry
+				ui.recheckConnection()
+				break stream
+			}
+		}
+		resync.Stop()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryInterval):
+		}
+	}
+}
+
+// updateContainer refreshes the row of one container after an event about it.
+func (ui *dockerUI) updateContainer(id string, action events.Action) {
+	if action == events.ActionDestroy {
+		ui.setContainer(id, nil)
+		return
+	}
+
+	cli, all := ui.client(), ui.showAll
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		containers, err := cli.ContainerList(ctx, container.ListOptions{All: all, Filters: filters.NewArgs(filters.Arg("id", id))})
+		if err != nil {
+			return // the next event or resync catches up
+		}
+		ui.app.QueueUpdateDraw(func() {
+			if cli != ui.client() {
+				return
+			}
+			if len(containers) == 0 {
+				ui.setContainer(id, nil)
+			} else {
+				ui.setContainer(id, &containers[0])
+			}
+		})
+	}()
+}
+
+// setContainer replaces, adds or (when c is nil) removes one container of the listing.
+func (ui *dockerUI) setContainer(id string, c *types.Container) {
+	containers := ui.containers
+	i := -1
+	for j := range containers {
+		if containers[j].ID == id {
+			i = j
+			break
+		}
+	}
+
+	switch {
+	case c == nil && i < 0:
+		return
+	case c == nil:
+		containers = slices.Delete(slices.Clone(containers), i, i+1)
+	case i < 0:
+		// The daemon lists the newest first
+		containers = append([]types.Container{*c}, containers...)
+	default:
+		containers = slices.Clone(containers)
+		containers[i] = *c
+	}
+	ui.setContainers(containers)
+}
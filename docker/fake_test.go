@@ -0,0 +1,328 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// fakeStatsInterval is how often the fake streams a stats sample.
+const fakeStatsInterval = 50 * time.Millisecond
+
+// fakeDocker is an in-memory daemon. It lists, inspects and runs the lifecycle
+// of its containers, streams their logs and stats, and publishes events as
+// they change. Exec and the resource pages aren't faked: the embedded nil
+// service panics if a test reaches them.
+type fakeDocker struct {
+	dockerService
+
+	mu         sync.Mutex
+	containers []types.Container // newest first, like the daemon lists them
+	logs       map[string][]fakeLogLine
+	usage      map[string]fakeUsage
+	pingErr    error
+	listed     int // ContainerList calls, to tell when a refresh happened
+	watchers   map[chan events.Message]bool
+}
+
+// fakeLogLine is a line of container output; Stderr picks the stream.
+type fakeLogLine struct {
+	Text   string
+	Stderr bool
+}
+
+// fakeUsage is what the stats stream of a running container reports.
+type fakeUsage struct {
+	CPU      float64 // percent of one CPU
+	MemUsage uint64
+	MemLimit uint64
+}
+
+func newFakeDocker(containers ...types.Container) *fakeDocker {
+	return &fakeDocker{
+		containers: containers,
+		logs:       make(map[string][]fakeLogLine),
+		usage:      make(map[string]fakeUsage),
+		watchers:   make(map[chan events.Message]bool),
+	}
+}
+
+// fakeContainer returns a container as the daemon lists it, running unless
+// state says otherwise. labels are key=value pairs.
+func fakeContainer(id, name, image, state string, labels ...string) types.Container {
+	status := "Up 2 minutes"
+	if state != "running" {
+		status = "Exited (0) 3 minutes ago"
+	}
+	c := types.Container{ID: id, Names: []string{"/" + name}, Image: image, State: state, Status: status,
+		Created: time.Now().Unix(), Labels: make(map[string]string)}
+	for _, label := range labels {
+		key, value, _ := strings.Cut(label, "=")
+		c.Labels[key] = value
+	}
+	return c
+}
+
+// add creates a container and publishes its events.
+func (f *fakeDocker) add(c types.Container) {
+	f.mu.Lock()
+	f.containers = append([]types.Container{c}, f.containers...)
+	f.mu.Unlock()
+	f.publish(c.ID, events.ActionCreate)
+	if c.State == "running" {
+		f.publish(c.ID, events.ActionStart)
+	}
+}
+
+// setLogs sets the output of a container.
+func (f *fakeDocker) setLogs(id string, lines ...fakeLogLine) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs[id] = lines
+}
+
+// setUsage sets what the stats of a container report from the next sample on.
+func (f *fakeDocker) setUsage(id string, usage fakeUsage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.usage[id] = usage
+}
+
+// setDown makes the daemon unreachable with err, or reachable again with nil.
+func (f *fakeDocker) setDown(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pingErr = err
+}
+
+// listCalls returns how often the containers were listed.
+func (f *fakeDocker) listCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.listed
+}
+
+func (f *fakeDocker) publish(id string, action events.Action) {
+	msg := events.Message{Type: events.ContainerEventType, Action: action, Actor: events.Actor{ID: id}, Time: time.Now().Unix()}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for watcher := range f.watchers {
+		watcher <- msg
+	}
+}
+
+// find returns the index of a container, or -1. f.mu must be held.
+func (f *fakeDocker) find(id string) int {
+	return slices.IndexFunc(f.containers, func(c types.Container) bool { return c.ID == id })
+}
+
+// update changes a container and publishes the action, or fails if it doesn't exist.
+func (f *fakeDocker) update(id string, action events.Action, change func(c *types.Container)) error {
+	f.mu.Lock()
+	i := f.find(id)
+	if i < 0 {
+		f.mu.Unlock()
+		return fmt.Errorf("No such container: %s", id)
+	}
+	change(&f.containers[i])
+	f.mu.Unlock()
+	f.publish(id, action)
+	return nil
+}
+
+func (f *fakeDocker) Ping(ctx context.Context) (types.Ping, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pingErr != nil {
+		return types.Ping{}, f.pingErr
+	}
+	return types.Ping{APIVersion: "1.45", OSType: "linux"}, nil
+}
+
+func (f *fakeDocker) Close() error { return nil }
+
+func (f *fakeDocker) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listed++
+	if f.pingErr != nil {
+		return nil, f.pingErr
+	}
+
+	ids := options.Filters.Get("id")
+	var result []types.Container
+	for _, c := range f.containers {
+		if !options.All && c.State != "running" {
+			continue
+		}
+		if len(ids) > 0 && !slices.ContainsFunc(ids, func(id string) bool { return strings.HasPrefix(c.ID, id) }) {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func (f *fakeDocker) ContainerInspect(ctx context.Context, id string) (types.ContainerJSON, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.find(id)
+	if i < 0 {
+		return types.ContainerJSON{}, fmt.Errorf("No such container: %s", id)
+	}
+	c := f.containers[i]
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: c.ID, Name: c.Names[0], Image: c.ImageID,
+			State: &types.ContainerState{Status: c.State, Running: c.State == "running"}},
+		Config: &container.Config{Image: c.Image, Labels: c.Labels},
+	}, nil
+}
+
+// ContainerLogs streams the lines set with setLogs, multiplexed as for a
+// container without a TTY, then stays open while following.
+func (f *fakeDocker) ContainerLogs(ctx context.Context, id string, options container.LogsOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	lines := f.logs[id]
+	f.mu.Unlock()
+
+	r, w := io.Pipe()
+	go func() {
+		stdout, stderr := stdcopy.NewStdWriter(w, stdcopy.Stdout), stdcopy.NewStdWriter(w, stdcopy.Stderr)
+		start := time.Now().Add(-time.Duration(len(lines)) * time.Second)
+		for i, line := range lines {
+			out := stdout
+			if line.Stderr {
+				out = stderr
+			}
+			text := line.Text + "\n"
+			if options.Timestamps {
+				text = start.Add(time.Duration(i)*time.Second).UTC().Format(time.RFC3339Nano) + " " + text
+			}
+			if _, err := out.Write([]byte(text)); err != nil {
+				return
+			}
+		}
+		if options.Follow {
+			<-ctx.Done()
+		}
+		w.Close()
+	}()
+	return r, nil
+}
+
+// ContainerStats streams a sample of the usage set with setUsage every
+// fakeStatsInterval, with counters that give exactly that CPU percentage.
+func (f *fakeDocker) ContainerStats(ctx context.Context, id string, stream bool) (container.StatsResponseReader, error) {
+	r, w := io.Pipe()
+	go func() {
+		defer w.Close()
+		encoder := json.NewEncoder(w)
+		var previous container.StatsResponse
+		for n := uint64(1); ; n++ {
+			f.mu.Lock()
+			usage := f.usage[id]
+			f.mu.Unlock()
+
+			var sample container.StatsResponse
+			sample.Read, sample.PreCPUStats = time.Now(), previous.CPUStats
+			sample.CPUStats.OnlineCPUs = 1
+			sample.CPUStats.SystemUsage = n * uint64(time.Second)
+			sample.CPUStats.CPUUsage.TotalUsage = previous.CPUStats.CPUUsage.TotalUsage + uint64(usage.CPU/100*float64(time.Second))
+			sample.MemoryStats.Usage, sample.MemoryStats.Limit = usage.MemUsage, usage.MemLimit
+			sample.PidsStats.Current = 3
+			if err := encoder.Encode(sample); err != nil || !stream {
+				return
+			}
+			previous = sample
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(fakeStatsInterval):
+			}
+		}
+	}()
+	return container.StatsResponseReader{Body: r, OSType: "linux"}, nil
+}
+
+// Events publishes the changes made through the fake until ctx is done.
+// Filters are ignored: only container events happen here.
+func (f *fakeDocker) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	messages, errs := make(chan events.Message, 100), make(chan error, 1)
+	f.mu.Lock()
+	if f.pingErr != nil {
+		errs <- f.pingErr
+		f.mu.Unlock()
+		return messages, errs
+	}
+	f.watchers[messages] = true
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.watchers, messages)
+		f.mu.Unlock()
+		errs <- ctx.Err()
+	}()
+	return messages, errs
+}
+
+func (f *fakeDocker) ContainerStart(ctx context.Context, id string, options container.StartOptions) error {
+	return f.update(id, events.ActionStart, func(c *types.Container) {
+		c.State, c.Status = "running", "Up Less than a second"
+	})
+}
+
+func (f *fakeDocker) ContainerStop(ctx context.Context, id string, options container.StopOptions) error {
+	return f.update(id, events.ActionStop, func(c *types.Container) {
+		c.State, c.Status = "exited", "Exited (0) Less than a second ago"
+	})
+}
+
+func (f *fakeDocker) ContainerRestart(ctx context.Context, id string, options container.StopOptions) error {
+	return f.update(id, events.ActionRestart, func(c *types.Container) {
+		c.State, c.Status = "running", "Up Less than a second"
+	})
+}
+
+func (f *fakeDocker) ContainerPause(ctx context.Context, id string) error {
+	return f.update(id, events.ActionPause, func(c *types.Container) {
+		c.State, c.Status = "paused", "Up 2 minutes (Paused)"
+	})
+}
+
+func (f *fakeDocker) ContainerUnpause(ctx context.Context, id string) error {
+	return f.update(id, events.ActionUnPause, func(c *types.Container) {
+		c.State, c.Status = "running", "Up 2 minutes"
+	})
+}
+
+func (f *fakeDocker) ContainerKill(ctx context.Context, id, signal string) error {
+	return f.update(id, events.ActionDie, func(c *types.Container) {
+		c.State, c.Status = "exited", "Exited (137) Less than a second ago"
+	})
+}
+
+func (f *fakeDocker) ContainerRemove(ctx context.Context, id string, options container.RemoveOptions) error {
+	f.mu.Lock()
+	i := f.find(id)
+	if i < 0 {
+		f.mu.Unlock()
+		return fmt.Errorf("No such container: %s", id)
+	}
+	f.containers = slices.Delete(f.containers, i, i+1)
+	f.mu.Unlock()
+	f.publish(id, events.ActionDestroy)
+	return nil
+}
@@ -0,0 +1,357 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antick/ok/utils"
+	"github.com/docker/docker/api/types"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// secretEnv matches environment variable names whose values are masked.
+var secretEnv = regexp.MustCompile(`(?i)(pass|secret|token|key|credential|auth|private)`)
+
+// inspectValue is the reference of a leaf node: what gets copied.
+type inspectValue string
+
+// maskedValue is the reference of a masked secret: its value is copied, but
+// only its name is echoed in the status bar.
+type maskedValue struct {
+	name, value string
+}
+
+// copyToClipboard is swapped out by tests.
+var copyToClipboard = utils.CopyToClipboard
+
+// inspectPage shows a container's inspect data as a collapsible tree.
+type inspectPage struct {
+	ui      *dockerUI
+	tree    *tview.TreeView
+	info    types.ContainerJSON
+	reveal  bool
+	section map[string]bool // sections collapsed by the user, kept across rebuilds
+}
+
+// showInspect opens the inspect page for the highlighted container.
+func (ui *dockerUI) showInspect() {
+	id, name, ok := ui.selectedContainer()
+	if !ok {
+		return
+	}
+	ui.setStatus("[yellow]Inspecting %s...", name)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		info, err := ui.client().ContainerInspect(ctx, id)
+		ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ui.setStatus("[red]Failed to inspect %s: %v", name, err)
+				return
+			}
+			ui.setStatus("")
+			page := &inspectPage{ui: ui, info: info, section: make(map[string]bool)}
+			page.open()
+		})
+	}()
+}
+
+func (p *inspectPage) open() {
+	p.tree = tview.NewTreeView().SetGraphicsColor(tcell.ColorGray)
+	p.tree.SetBorder(true).
+		SetTitle(" Inspect " + strings.TrimPrefix(p.info.Name, "/") + " — enter:expand/collapse  y:copy  m:show/hide secrets  e/c:expand/collapse all  esc:back ")
+	p.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+		if len(node.GetChildren()) > 0 {
+			p.section[node.GetText()] = !node.IsExpanded()
+		}
+	})
+	p.tree.SetInputCapture(p.handleKey)
+	p.build()
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(p.tree, 0, 1, true).
+		AddItem(p.ui.statusRow, 1, 0, false)
+	p.ui.pages.AddPage("inspect", layout, true, true)
+	p.ui.app.SetFocus(p.tree)
+}
+
+func (p *inspectPage) close() {
+	p.ui.pages.RemovePage("inspect")
+	p.ui.app.SetFocus(p.ui.containerList)
+}
+
+func (p *inspectPage) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEscape {
+		p.close()
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'q':
+		p.close()
+	case 'y':
+		p.copy(p.tree.GetCurrentNode())
+	case 'm':
+		p.reveal = !p.reveal
+		p.build()
+	case 'e':
+		p.tree.GetRoot().ExpandAll()
+		p.section = make(map[string]bool)
+	case 'c':
+		for _, node := range p.tree.GetRoot().GetChildren() {
+			node.Collapse()
+			p.section[node.GetText()] = true
+		}
+	default:
+		return event
+	}
+	return nil
+}
+
+// copy puts a leaf's value, or a section rendered as indented text, on the clipboard.
+func (p *inspectPage) copy(node *tview.TreeNode) {
+	if node == nil {
+		return
+	}
+
+	var text, copied string
+	switch value := node.GetReference().(type) {
+	case inspectValue:
+		text = string(value)
+		copied = fmt.Sprintf("%q", firstLine(text))
+	case maskedValue:
+		text = value.value
+		copied = "value of " + value.name
+	default:
+		text = sectionText(node)
+		copied = fmt.Sprintf("%q", firstLine(text))
+	}
+
+	if err := copyToClipboard(text); err != nil {
+		p.ui.setStatus("[red]%v", err)
+		return
+	}
+	p.ui.setStatus("[green]Copied %s", tview.Escape(copied))
+}
+
+// sectionText renders a section as indented text. It takes what the tree
+// shows rather than the leaves' values, so masked secrets stay masked unless
+// they were revealed.
+func sectionText(node *tview.TreeNode) string {
+	var b strings.Builder
+	node.Walk(func(n, parent *tview.TreeNode) bool {
+		b.WriteString(strings.Repeat("  ", n.GetLevel()-node.GetLevel()) + n.GetText() + "\n")
+		return true
+	})
+	return b.String()
+}
+
+// build (re)creates the tree, keeping the current selection and collapsed sections.
+func (p *inspectPage) build() {
+	selected := p.currentPath()
+
+	info := p.info
+	root := tview.NewTreeNode(fmt.Sprintf("%s (%s)", strings.TrimPrefix(info.Name, "/"), shortID(info.ID))).
+		SetColor(tcell.ColorYellow)
+
+	general := p.addSection(root, "General")
+	addField(general, "ID", info.ID)
+	addField(general, "Created", info.Created)
+	addField(general, "Command", strings.TrimSpace(info.Path+" "+strings.Join(info.Args, " ")))
+	if info.Config != nil {
+		addField(general, "Image", info.Config.Image)
+		addField(general, "Entrypoint", strings.Join(info.Config.Entrypoint, " "))
+		addField(general, "Working dir", info.Config.WorkingDir)
+		addField(general, "User", info.Config.User)
+		addField(general, "Hostname", info.Config.Hostname)
+	}
+	if info.HostConfig != nil {
+		policy := string(info.HostConfig.RestartPolicy.Name)
+		if info.HostConfig.RestartPolicy.MaximumRetryCount > 0 {
+			policy += fmt.Sprintf(" (max %d retries)", info.HostConfig.RestartPolicy.MaximumRetryCount)
+		}
+		addField(general, "Restart policy", policy)
+	}
+	addField(general, "Restart count", fmt.Sprint(info.RestartCount))
+
+	if state := info.State; state != nil {
+		section := p.addSection(root, "State")
+		addField(section, "Status", state.Status)
+		addField(section, "Started at", state.StartedAt)
+		if !state.Running {
+			addField(section, "Finished at", state.FinishedAt)
+			addField(section, "Exit code", fmt.Sprint(state.ExitCode))
+		}
+		if state.Pid > 0 {
+			addField(section, "PID", fmt.Sprint(state.Pid))
+		}
+		if state.OOMKilled {
+			addField(section, "OOM killed", "true")
+		}
+		addField(section, "Error", state.Error)
+
+		if health := state.Health; health != nil {
+			section := p.addSection(root, "Health")
+			addField(section, "Status", health.Status)
+			addField(section, "Failing streak", fmt.Sprint(health.FailingStreak))
+			if info.Config != nil && info.Config.Healthcheck != nil {
+				addField(section, "Check", strings.Join(info.Config.Healthcheck.Test, " "))
+			}
+			if n := len(health.Log); n > 0 {
+				last := health.Log[n-1]
+				addField(section, "Last output", strings.TrimSpace(last.Output))
+				addField(section, "Last exit code", fmt.Sprint(last.ExitCode))
+			}
+		}
+	}
+
+	if info.Config != nil && len(info.Config.Env) > 0 {
+		section := p.addSection(root, fmt.Sprintf("Environment (%d)", len(info.Config.Env)))
+		for _, env := range info.Config.Env {
+			key, value, _ := strings.Cut(env, "=")
+			if !p.reveal && secretEnv.MatchString(key) && value != "" {
+				section.AddChild(tview.NewTreeNode(key + "=********").SetReference(maskedValue{name: key, value: value}))
+				continue
+			}
+			section.AddChild(tview.NewTreeNode(key + "=" + value).SetReference(inspectValue(value)))
+		}
+	}
+
+	if len(info.Mounts) > 0 {
+		section := p.addSection(root, fmt.Sprintf("Mounts (%d)", len(info.Mounts)))
+		for _, m := range info.Mounts {
+			source := m.Source
+			if m.Name != "" {
+				source = m.Name
+			}
+			mode := "ro"
+			if m.RW {
+				mode = "rw"
+			}
+			section.AddChild(tview.NewTreeNode(fmt.Sprintf("%s <- %s (%s, %s)", m.Destination, source, m.Type, mode)).
+				SetReference(inspectValue(m.Source)))
+		}
+	}
+
+	if info.NetworkSettings != nil {
+		if networks := info.NetworkSettings.Networks; len(networks) > 0 {
+			section := p.addSection(root, fmt.Sprintf("Networks (%d)", len(networks)))
+			for _, name := range sortedKeys(networks) {
+				endpoint := networks[name]
+				node := tview.NewTreeNode(name).SetColor(tcell.ColorTeal)
+				addField(node, "IP", endpoint.IPAddress)
+				addField(node, "IPv6", endpoint.GlobalIPv6Address)
+				addField(node, "Gateway", endpoint.Gateway)
+				addField(node, "MAC", endpoint.MacAddress)
+				addField(node, "Aliases", strings.Join(endpoint.Aliases, ", "))
+				section.AddChild(node)
+			}
+		}
+
+		if ports := info.NetworkSettings.Ports; len(ports) > 0 {
+			section := p.addSection(root, "Ports")
+			for _, port := range sortedKeys(ports) {
+				bindings := ports[port]
+				if len(bindings) == 0 {
+					section.AddChild(tview.NewTreeNode(string(port) + " (not published)").SetReference(inspectValue(port)))
+					continue
+				}
+				for _, b := range bindings {
+					host := b.HostIP + ":" + b.HostPort
+					section.AddChild(tview.NewTreeNode(string(port) + " -> " + host).SetReference(inspectValue(host)))
+				}
+			}
+		}
+	}
+
+	if info.Config != nil && len(info.Config.Labels) > 0 {
+		section := p.addSection(root, fmt.Sprintf("Labels (%d)", len(info.Config.Labels)))
+		for _, key := range sortedKeys(info.Config.Labels) {
+			addField(section, key, info.Config.Labels[key])
+		}
+	}
+
+	p.tree.SetRoot(root).SetCurrentNode(root)
+	node := root
+	for _, i := range selected {
+		children := node.GetChildren()
+		if i >= len(children) {
+			break
+		}
+		node = children[i]
+	}
+	p.tree.SetCurrentNode(node)
+}
+
+// currentPath returns the child indexes leading from the root to the selected
+// node, which survive a rebuild even when the node's text changes.
+func (p *inspectPage) currentPath() []int {
+	current, root := p.tree.GetCurrentNode(), p.tree.GetRoot()
+	if current == nil || root == nil {
+		return nil
+	}
+
+	var path []int
+	var find func(node *tview.TreeNode) bool
+	find = func(node *tview.TreeNode) bool {
+		if node == current {
+			return true
+		}
+		for i, child := range node.GetChildren() {
+			path = append(path, i)
+			if find(child) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	find(root)
+	return path
+}
+
+// addSection adds a collapsible section, collapsed if the user collapsed it before.
+func (p *inspectPage) addSection(parent *tview.TreeNode, title string) *tview.TreeNode {
+	node := tview.NewTreeNode(title).SetColor(tcell.ColorYellow).SetExpanded(!p.section[title])
+	parent.AddChild(node)
+	return node
+}
+
+// addField adds a "name: value" leaf, skipping empty values.
+func addField(parent *tview.TreeNode, name, value string) {
+	if value == "" {
+		return
+	}
+	parent.AddChild(tview.NewTreeNode(name + ": " + value).SetReference(inspectValue(value)))
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	if len(line) > 40 {
+		line = line[:40] + "..."
+	}
+	return line
+}
@@ -0,0 +1,59 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antick/ok/utils"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/rivo/tview"
+)
+
+func TestCopyKeepsSecretsMasked(t *testing.T) {
+	p := &inspectPage{
+		ui:   &dockerUI{statusBar: tview.NewTextView().SetDynamicColors(true)},
+		tree: tview.NewTreeView(),
+		info: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: "aaaa00000001", Name: "/shop-db-1"},
+			Config:            &container.Config{Env: []string{"POSTGRES_PASSWORD=hunter2", "PGDATA=/var/lib/postgresql/data"}},
+		},
+		section: make(map[string]bool),
+	}
+
+	env := func() *tview.TreeNode {
+		p.build()
+		for _, node := range p.tree.GetRoot().GetChildren() {
+			if strings.HasPrefix(node.GetText(), "Environment") {
+				return node
+			}
+		}
+		t.Fatal("no environment section")
+		return nil
+	}
+
+	section := env()
+	if text := sectionText(section); strings.Contains(text, "hunter2") || !strings.Contains(text, "POSTGRES_PASSWORD=********") {
+		t.Errorf("masked section copies as:\n%s", text)
+	}
+
+	// A single variable copies its real value without echoing it
+	var copied string
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	t.Cleanup(func() { copyToClipboard = utils.CopyToClipboard })
+	p.copy(section.GetChildren()[0])
+	if copied != "hunter2" {
+		t.Errorf("a single variable copies %q, want its real value", copied)
+	}
+	if status := p.ui.statusBar.GetText(true); strings.Contains(status, "hunter2") || !strings.Contains(status, "POSTGRES_PASSWORD") {
+		t.Errorf("the status bar shows %q after copying a masked variable", status)
+	}
+
+	p.reveal = true
+	if text := sectionText(env()); !strings.Contains(text, "POSTGRES_PASSWORD=hunter2") {
+		t.Errorf("revealed section copies as:\n%s", text)
+	}
+}
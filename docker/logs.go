@@ -0,0 +1,482 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// logTail is how much history is fetched when following a container
+	logTail = "200"
+	// maxLogLines caps the scroll-back buffer of the log panel
+	maxLogLines = 5000
+	// logFlushInterval batches incoming lines so busy containers don't redraw per line
+	logFlushInterval = 200 * time.Millisecond
+)
+
+// logLine is one line of container output.
+type logLine struct {
+	Time   time.Time
+	Stderr bool
+	Text   string
+	// Source and Color tell services apart when following several containers
+	Source string
+	Color  string
+}
+
+// format renders the line as plain text, as saved to a file.
+func (l logLine) format(timestamps bool) string {
+	text := l.Text
+	if l.Source != "" {
+		text = l.Source + " | " + text
+	}
+	if timestamps && !l.Time.IsZero() {
+		return l.Time.Local().Format(time.RFC3339Nano) + " " + text
+	}
+	return text
+}
+
+// logTarget is a container whose logs are followed. Prefix is empty when
+// following a single container.
+type logTarget struct {
+	ID     string
+	Prefix string
+	Color  string
+}
+
+// logPanel streams the logs of one container into a scrollable view.
+type logPanel struct {
+	ui     *dockerUI
+	view   *tview.TextView
+	filter *tview.InputField
+	layout *tview.Flex
+
+	mu      sync.Mutex
+	lines   []logLine
+	pending []logLine
+	cancel  context.CancelFunc
+
+	// Only touched on the UI goroutine
+	name       string
+	query      string
+	queryRE    *regexp.Regexp // query compiled for highlight, nil without one
+	timestamps bool
+	paused     bool
+	unseen     int
+}
+
+func newLogPanel(ui *dockerUI) *logPanel {
+	p := &logPanel{ui: ui}
+
+	p.view = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetMaxLines(maxLogLines)
+	p.view.SetInputCapture(p.handleKey)
+
+	p.filter = tview.NewInputField().
+		SetLabel("/").
+		SetFieldBackgroundColor(tcell.ColorBlack).
+		SetChangedFunc(func(text string) {
+			p.query, p.queryRE = text, nil
+			if text != "" {
+				p.queryRE = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+			}
+			p.render()
+		}).
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEscape {
+				p.filter.SetText("")
+			}
+			if p.query == "" {
+				p.layout.ResizeItem(p.filter, 0, 0)
+			}
+			p.ui.app.SetFocus(p.view)
+		})
+
+	p.layout = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(p.view, 0, 1, true).
+		AddItem(p.filter, 0, 0, false)
+	p.layout.SetBorder(true)
+	p.updateTitle()
+	return p
+}
+
+// follow starts streaming the logs of one or more containers under a title,
+// replacing any previous stream.
+func (p *logPanel) follow(name string, targets ...logTarget) {
+	p.stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.mu.Lock()
+	p.lines, p.pending, p.cancel = nil, nil, cancel
+	p.mu.Unlock()
+
+	p.name, p.paused, p.unseen = name, false, 0
+	p.view.Clear()
+	p.updateTitle()
+
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target logTarget) {
+			defer wg.Done()
+			if err := p.stream(ctx, target); err != nil {
+				failed.Store(true)
+			}
+		}(target)
+	}
+	go func() {
+		wg.Wait()
+		// Failures were reported already
+		if !failed.Load() {
+			p.streamEnded(ctx, name, nil)
+		}
+	}()
+	go p.flushLoop(ctx)
+}
+
+// stop ends the current stream, keeping its lines on screen.
+func (p *logPanel) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
+
+// stream copies the logs of one container into the panel until they end,
+// reporting and returning the error if they end abnormally.
+func (p *logPanel) stream(ctx context.Context, target logTarget) error {
+	name := target.Prefix
+	if name == "" {
+		name = shortID(target.ID)
+	}
+
+	add := func(line logLine) { p.add(ctx, line) }
+	if err := streamLogs(ctx, p.ui.client(), target, true, logTail, add); err != nil {
+		p.streamEnded(ctx, name, err)
+		return err
+	}
+	return nil
+}
+
+// streamLogs passes the lines of a container's logs to emit, starting with the
+// last tail lines, until they end or, when following, the context is done.
+func streamLogs(ctx context.Context, cli dockerService, target logTarget, follow bool, tail string, emit func(logLine)) error {
+	info, err := cli.ContainerInspect(ctx, target.ID)
+	if err != nil {
+		return err
+	}
+
+	logs, err := cli.ContainerLogs(ctx, target.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     follow,
+		Timestamps: true,
+		Tail:       tail,
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	stdout := &logWriter{emit: emit, target: target}
+	stderr := &logWriter{emit: emit, target: target, stderr: true}
+
+	// Without a TTY the stream is multiplexed with 8-byte frame headers
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(stdout, logs)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, logs)
+	}
+	stdout.flush()
+	stderr.flush()
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// streamEnded reports why a stream stopped, unless it was replaced on purpose.
+func (p *logPanel) streamEnded(ctx context.Context, name string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	p.ui.app.QueueUpdateDraw(func() {
+		if err != nil {
+			p.ui.setStatus("[red]Logs of %s stopped: %v", name, err)
+		} else {
+			p.ui.setStatus("[yellow]Log stream of %s ended", name)
+		}
+	})
+}
+
+// add queues a line of the stream of ctx. Lines a replaced stream had already
+// read are dropped: follow cancels ctx under p.mu before resetting the buffer,
+// so a line checked here can't outlive the switch.
+func (p *logPanel) add(ctx context.Context, line logLine) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	p.pending = append(p.pending, line)
+}
+
+// flushLoop moves pending lines into the buffer and onto the screen.
+func (p *logPanel) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		// The tick and the cancellation raced, the pending lines are the next stream's
+		if ctx.Err() != nil {
+			p.mu.Unlock()
+			return
+		}
+		fresh := p.pending
+		p.pending = nil
+		// Streams of several containers arrive in bursts, order each batch by time
+		sort.SliceStable(fresh, func(i, j int) bool { return fresh[i].Time.Before(fresh[j].Time) })
+		p.lines = append(p.lines, fresh...)
+		if len(p.lines) > maxLogLines {
+			p.lines = append([]logLine(nil), p.lines[len(p.lines)-maxLogLines:]...)
+		}
+		p.mu.Unlock()
+
+		if len(fresh) == 0 {
+			continue
+		}
+		p.ui.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if p.paused {
+				p.unseen += len(fresh)
+				p.updateTitle()
+				return
+			}
+			p.write(fresh)
+		})
+	}
+}
+
+// render redraws the whole buffer, after the filter or timestamps changed.
+func (p *logPanel) render() {
+	p.mu.Lock()
+	lines := append([]logLine(nil), p.lines...)
+	p.mu.Unlock()
+
+	p.view.Clear()
+	p.write(lines)
+	p.updateTitle()
+}
+
+func (p *logPanel) write(lines []logLine) {
+	match := p.matcher()
+	w := p.view.BatchWriter()
+	defer w.Close()
+
+	for _, line := range lines {
+		if !match(line.Text) {
+			continue
+		}
+		text := tview.Escape(line.Text)
+		if p.queryRE != nil {
+			text = highlight(line.Text, p.queryRE)
+		}
+		if line.Stderr {
+			text = "[red]" + text + "[-]"
+		}
+		if line.Source != "" {
+			text = "[" + line.Color + "]" + tview.Escape(line.Source) + " |[-] " + text
+		}
+		if p.timestamps && !line.Time.IsZero() {
+			text = "[gray]" + line.Time.Local().Format("15:04:05.000") + "[-] " + text
+		}
+		fmt.Fprintln(w, text)
+	}
+	if !p.paused {
+		p.view.ScrollToEnd()
+	}
+}
+
+// matcher returns a case-insensitive substring match for the current filter.
+func (p *logPanel) matcher() func(string) bool {
+	if p.query == "" {
+		return func(string) bool { return true }
+	}
+	query := strings.ToLower(p.query)
+	return func(s string) bool {
+		return strings.Contains(strings.ToLower(s), query)
+	}
+}
+
+// highlight escapes s and marks every match of re.
+func highlight(s string, re *regexp.Regexp) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(s, -1) {
+		b.WriteString(tview.Escape(s[last:m[0]]))
+		b.WriteString("[black:yellow]" + tview.Escape(s[m[0]:m[1]]) + "[-:-]")
+		last = m[1]
+	}
+	b.WriteString(tview.Escape(s[last:]))
+	return b.String()
+}
+
+func (p *logPanel) setPaused(paused bool) {
+	if p.paused == paused {
+		return
+	}
+	p.paused = paused
+	if !paused {
+		p.unseen = 0
+		p.render()
+	}
+	p.updateTitle()
+}
+
+func (p *logPanel) updateTitle() {
+	title := "Container Logs"
+	if p.name != "" {
+		title += ": " + p.name
+	}
+	if p.paused {
+		title += fmt.Sprintf(" [yellow]PAUSED (%d new)[-]", p.unseen)
+	}
+	if p.query != "" {
+		title += " [filter: " + tview.Escape(p.query) + "[]"
+	}
+	p.layout.SetTitle(title)
+}
+
+// save writes the whole buffer to a file in the working directory.
+func (p *logPanel) save() {
+	p.mu.Lock()
+	lines := append([]logLine(nil), p.lines...)
+	p.mu.Unlock()
+
+	if p.name == "" {
+		return
+	}
+	path := fmt.Sprintf("%s-%s.log", p.name, time.Now().Format("20060102-150405"))
+
+	var b bytes.Buffer
+	for _, line := range lines {
+		b.WriteString(line.format(true))
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		p.ui.setStatus("[red]Failed to save logs: %v", err)
+		return
+	}
+	p.ui.setStatus("[green]Saved %d lines to %s", len(lines), path)
+}
+
+// handleKey implements the log panel keys; scrolling is left to the TextView.
+func (p *logPanel) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyUp, tcell.KeyPgUp, tcell.KeyHome:
+		p.setPaused(true)
+		return event
+	case tcell.KeyEnd:
+		p.setPaused(false)
+		return event
+	case tcell.KeyTab, tcell.KeyEscape:
+		p.ui.app.SetFocus(p.ui.containerList)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'k', 'g':
+		p.setPaused(true)
+		return event
+	case 'G':
+		p.setPaused(false)
+		return event
+	case ' ':
+		p.setPaused(!p.paused)
+	case 't':
+		p.timestamps = !p.timestamps
+		p.render()
+	case '/':
+		p.layout.ResizeItem(p.filter, 1, 0)
+		p.ui.app.SetFocus(p.filter)
+	case 'w':
+		p.save()
+	case 'c':
+		p.mu.Lock()
+		p.lines = nil
+		p.mu.Unlock()
+		p.view.Clear()
+	default:
+		return event
+	}
+	return nil
+}
+
+// logWriter splits a log stream into lines, parsing the timestamp prefix the
+// daemon adds when Timestamps is set.
+type logWriter struct {
+	emit    func(logLine)
+	target  logTarget
+	stderr  bool
+	partial []byte
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	w.partial = append(w.partial, b...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.line(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (w *logWriter) flush() {
+	if len(w.partial) > 0 {
+		w.line(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *logWriter) line(s string) {
+	line := logLine{Stderr: w.stderr, Source: w.target.Prefix, Color: w.target.Color}
+	s = strings.TrimSuffix(s, "\r")
+	if stamp, rest, ok := strings.Cut(s, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			line.Time, s = t, rest
+		}
+	}
+	line.Text = s
+	w.emit(line)
+}
@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// lookupTimeout bounds calls made on behalf of other commands, so that an
+// unreachable daemon does not hold up e.g. `ok kill`.
+const lookupTimeout = 3 * time.Second
+
+// PublishedContainer is a running container publishing host ports.
+type PublishedContainer struct {
+	ID    string
+	Name  string
+	Image string
+	Ports []int // published host ports that were asked about
+}
+
+// ContainersPublishing returns the running containers that publish any of
+// ports on the host for the given protocol ("tcp" or "udp").
+func ContainersPublishing(ports []int, proto string) ([]PublishedContainer, error) {
+	cli, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	containers, err := cli.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[int]bool{}
+	for _, p := range ports {
+		wanted[p] = true
+	}
+
+	var res []PublishedContainer
+	for _, c := range containers {
+		pc := PublishedContainer{ID: shortID(c.ID), Name: containerName(c), Image: c.Image}
+		seen := map[int]bool{}
+		for _, p := range c.Ports {
+			port := int(p.PublicPort)
+			// IPv4 and IPv6 bindings of the same port are listed separately
+			if wanted[port] && strings.EqualFold(p.Type, proto) && !seen[port] {
+				seen[port] = true
+				pc.Ports = append(pc.Ports, port)
+			}
+		}
+		if len(pc.Ports) > 0 {
+			sort.Ints(pc.Ports)
+			res = append(res, pc)
+		}
+	}
+	return res, nil
+}
+
+// StopContainer stops a container, giving it timeout to exit before the
+// daemon kills it.
+func StopContainer(id string, timeout time.Duration) error {
+	cli, err := newClient()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	secs := int(timeout.Seconds())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+lookupTimeout)
+	defer cancel()
+	if err := cli.ContainerStop(ctx, id, container.StopOptions{Timeout: &secs}); err != nil {
+		return fmt.Errorf("error stopping container %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// resourceRow is one line of a resource table. ID is what delete acts on.
+type resourceRow struct {
+	ID    string
+	Name  string
+	Cells []string
+}
+
+// resourceKind describes a page of Docker objects other than containers.
+type resourceKind struct {
+	Name    string // as typed after ':'
+	Title   string
+	Headers []string
+	List    func(ctx context.Context, cli dockerService) ([]resourceRow, error)
+	Remove  func(ctx context.Context, cli dockerService, id string) error
+	// Prune removes unused objects and describes what it freed
+	Prune     func(ctx context.Context, cli dockerService) (string, error)
+	PruneWhat string // shown in the prune confirmation
+}
+
+var resourceKinds = map[string]resourceKind{
+	"images": {
+		Name:    "images",
+		Title:   "Images",
+		Headers: []string{"ID", "Repository:Tag", "Size", "Created", "Containers"},
+		List:    listImages,
+		Remove: func(ctx context.Context, cli dockerService, id string) error {
+			_, err := cli.ImageRemove(ctx, id, image.RemoveOptions{PruneChildren: true})
+			return err
+		},
+		Prune:     pruneImages,
+		PruneWhat: "all dangling images",
+	},
+	"volumes": {
+		Name:    "volumes",
+		Title:   "Volumes",
+		Headers: []string{"Name", "Driver", "Mountpoint", "In use by"},
+		List:    listVolumes,
+		Remove: func(ctx context.Context, cli dockerService, id string) error {
+			return cli.VolumeRemove(ctx, id, false)
+		},
+		Prune:     pruneVolumes,
+		PruneWhat: "all unused anonymous volumes",
+	},
+	"networks": {
+		Name:    "networks",
+		Title:   "Networks",
+		Headers: []string{"ID", "Name", "Driver", "Subnet", "Containers"},
+		List:    listNetworks,
+		Remove: func(ctx context.Context, cli dockerService, id string) error {
+			return cli.NetworkRemove(ctx, id)
+		},
+		Prune:     pruneNetworks,
+		PruneWhat: "all unused networks",
+	},
+}
+
+func listImages(ctx context.Context, cli dockerService) ([]resourceRow, error) {
+	images, err := cli.ImageList(ctx, image.ListOptions{ContainerCount: true})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Created > images[j].Created })
+
+	var rows []resourceRow
+	for _, img := range images {
+		id := shortID(strings.TrimPrefix(img.ID, "sha256:"))
+		tags := imageTags(img)
+		containers := "-"
+		if img.Containers >= 0 {
+			containers = fmt.Sprint(img.Containers)
+		}
+		rows = append(rows, resourceRow{
+			ID:    img.ID,
+			Name:  tags,
+			Cells: []string{id, tags, formatBytes(float64(img.Size)), since(time.Unix(img.Created, 0)), containers},
+		})
+	}
+	return rows, nil
+}
+
+// imageTags joins an image's tags, or marks it dangling when it has none.
+func imageTags(img image.Summary) string {
+	var tags []string
+	for _, tag := range img.RepoTags {
+		if tag != "<none>:<none>" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return "<dangling>"
+	}
+	return strings.Join(tags, ", ")
+}
+
+func listVolumes(ctx context.Context, cli dockerService) ([]resourceRow, error) {
+	resp, err := cli.VolumeList(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string][]string)
+	for _, c := range containers {
+		for _, m := range c.Mounts {
+			if m.Name != "" {
+				users[m.Name] = append(users[m.Name], containerName(c))
+			}
+		}
+	}
+
+	sort.Slice(resp.Volumes, func(i, j int) bool { return resp.Volumes[i].Name < resp.Volumes[j].Name })
+	var rows []resourceRow
+	for _, v := range resp.Volumes {
+		inUse := strings.Join(users[v.Name], ", ")
+		if inUse == "" {
+			inUse = "-"
+		}
+		rows = append(rows, resourceRow{
+			ID:    v.Name,
+			Name:  v.Name,
+			Cells: []string{v.Name, v.Driver, v.Mountpoint, inUse},
+		})
+	}
+	return rows, nil
+}
+
+func listNetworks(ctx context.Context, cli dockerService) ([]resourceRow, error) {
+	networks, err := cli.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// Listing networks leaves out their containers, so map them from the other side
+	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	attached := make(map[string][]string)
+	for _, c := range containers {
+		if c.NetworkSettings == nil {
+			continue
+		}
+		for _, endpoint := range c.NetworkSettings.Networks {
+			if endpoint != nil {
+				attached[endpoint.NetworkID] = append(attached[endpoint.NetworkID], containerName(c))
+			}
+		}
+	}
+
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+	var rows []resourceRow
+	for _, n := range networks {
+		var subnets []string
+		for _, config := range n.IPAM.Config {
+			if config.Subnet != "" {
+				subnets = append(subnets, config.Subnet)
+			}
+		}
+		names := strings.Join(attached[n.ID], ", ")
+		if names == "" {
+			names = "-"
+		}
+		rows = append(rows, resourceRow{
+			ID:    n.ID,
+			Name:  n.Name,
+			Cells: []string{shortID(n.ID), n.Name, n.Driver, strings.Join(subnets, ", "), names},
+		})
+	}
+	return rows, nil
+}
+
+func pruneContainers(ctx context.Context, cli dockerService) (string, error) {
+	report, err := cli.ContainersPrune(ctx, filters.NewArgs())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Removed %s, reclaimed %s", plural(len(report.ContainersDeleted), "container"), formatBytes(float64(report.SpaceReclaimed))), nil
+}
+
+func pruneImages(ctx context.Context, cli dockerService) (string, error) {
+	report, err := cli.ImagesPrune(ctx, filters.NewArgs(filters.Arg("dangling", "true")))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Removed %s, reclaimed %s", plural(len(report.ImagesDeleted), "image"), formatBytes(float64(report.SpaceReclaimed))), nil
+}
+
+func pruneVolumes(ctx context.Context, cli dockerService) (string, error) {
+	report, err := cli.VolumesPrune(ctx, filters.NewArgs())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Removed %s, reclaimed %s", plural(len(report.VolumesDeleted), "volume"), formatBytes(float64(report.SpaceReclaimed))), nil
+}
+
+func pruneNetworks(ctx context.Context, cli dockerService) (string, error) {
+	report, err := cli.NetworksPrune(ctx, filters.NewArgs())
+	if err != nil {
+		return "", err
+	}
+	return "Removed " + plural(len(report.NetworksDeleted), "network"), nil
+}
+
+// resourcePage is the table of one resource kind, shown instead of the dashboard.
+type resourcePage struct {
+	ui    *dockerUI
+	kind  resourceKind
+	table *tview.Table
+	rows  []resourceRow
+}
+
+// showResources replaces the dashboard with the page of a resource kind.
+func (ui *dockerUI) showResources(kind resourceKind) {
+	p := &resourcePage{ui: ui, kind: kind}
+	p.table = tview.NewTable().SetSelectable(true, false).SetBorders(true).SetFixed(1, 0)
+	p.table.SetTitle(kind.Title).SetBorder(true)
+	p.table.SetInputCapture(p.handleKey)
+
+	helpBar := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText("[::b]=[::-]:refresh  [::b]D[::-]:delete  [::b]P[::-]:prune  [::b]esc[::-]:containers  [::b]:images :volumes :networks[::-]  [::b]:q[::-]:quit")
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(p.table, 0, 1, true).
+		AddItem(ui.statusRow, 1, 0, false).
+		AddItem(helpBar, 1, 0, false)
+
+	ui.pages.RemovePage("resources")
+	ui.pages.AddAndSwitchToPage("resources", layout, true)
+	ui.pages.AddPage("input", ui.inputOverlay, true, false)
+	ui.app.SetFocus(p.table)
+	p.refresh()
+}
+
+// showContainers goes back to the dashboard from a resource page.
+func (ui *dockerUI) showContainers() {
+	ui.pages.RemovePage("resources")
+	ui.pages.SwitchToPage("main")
+	ui.pages.AddPage("input", ui.inputOverlay, true, false)
+	ui.app.SetFocus(ui.containerList)
+}
+
+func (p *resourcePage) refresh() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		rows, err := p.kind.List(ctx, p.ui.client())
+		p.ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				p.ui.setStatus("[red]Failed to list %s: %v", p.kind.Name, err)
+				return
+			}
+			p.render(rows)
+		})
+	}()
+}
+
+func (p *resourcePage) render(rows []resourceRow) {
+	selected, _ := p.table.GetSelection()
+	p.rows = rows
+
+	p.table.Clear()
+	for col, header := range p.kind.Headers {
+		p.table.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	for i, row := range rows {
+		for col, text := range row.Cells {
+			cell := tview.NewTableCell(tview.Escape(text))
+			if text == "<dangling>" || text == "-" {
+				cell.SetTextColor(tcell.ColorGray)
+			}
+			p.table.SetCell(i+1, col, cell)
+		}
+	}
+	p.table.SetTitle(fmt.Sprintf("%s (%d)", p.kind.Title, len(rows)))
+
+	if selected >= len(rows)+1 {
+		selected = len(rows)
+	}
+	if selected < 1 {
+		selected = 1
+	}
+	p.table.Select(selected, 0)
+}
+
+func (p *resourcePage) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEscape {
+		p.ui.showContainers()
+		return nil
+	}
+
+	switch event.Rune() {
+	case '=':
+		p.refresh()
+	case 'D':
+		row, _ := p.table.GetSelection()
+		if row < 1 || row > len(p.rows) {
+			return nil
+		}
+		target := p.rows[row-1]
+		noun := strings.TrimSuffix(p.kind.Name, "s")
+		p.ui.confirm(fmt.Sprintf("Delete %s %s?", noun, target.Name), func() {
+			p.run(fmt.Sprintf("Deleting %s %s", noun, target.Name), func(ctx context.Context) (string, error) {
+				return fmt.Sprintf("Deleted %s %s", noun, target.Name), p.kind.Remove(ctx, p.ui.client(), target.ID)
+			})
+		})
+	case 'P':
+		p.ui.confirm(fmt.Sprintf("Prune %s?", p.kind.PruneWhat), func() {
+			p.run("Pruning "+p.kind.Name, func(ctx context.Context) (string, error) {
+				return p.kind.Prune(ctx, p.ui.client())
+			})
+		})
+	default:
+		return event
+	}
+	return nil
+}
+
+// run performs a delete or prune in the background and refreshes the table.
+func (p *resourcePage) run(progress string, action func(ctx context.Context) (string, error)) {
+	p.ui.setStatus("[yellow]%s...", progress)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		result, err := action(ctx)
+		p.ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				p.ui.setStatus("[red]%s failed: %v", progress, err)
+			} else {
+				p.ui.setStatus("[green]%s", result)
+			}
+			p.ui.app.SetFocus(p.table)
+			p.refresh()
+		})
+	}()
+}
+
+// since renders how long ago t was, e.g. "3 days ago".
+func since(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d.Hours()/24), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return plural(int(d.Hours()/24/30), "month") + " ago"
+	default:
+		return plural(int(d.Hours()/24/365), "year") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// containerName is the primary name of a listed container, without the slash.
+func containerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return shortID(c.ID)
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/docker/docker/api/types/container"
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// DefaultShell is started by the shell key when no shell is configured.
+const DefaultShell = "/bin/sh"
+
+// openShell suspends the UI and runs an interactive shell in the selected
+// container, resuming once the shell exits.
+func (ui *dockerUI) openShell() {
+	id, name, ok := ui.selectedContainer()
+	if !ok {
+		return
+	}
+
+	shell := ui.opts.Shell
+	if shell == "" {
+		shell = DefaultShell
+	}
+
+	var err error
+	ui.app.Suspend(func() {
+		fmt.Printf("Connecting to %s (%s), exit the shell to return...\n", name, shell)
+		err = ui.execShell(id, shell)
+	})
+
+	if err != nil {
+		ui.setStatus("[red]Shell in %s failed: %v", name, err)
+	} else {
+		ui.setStatus("[green]Shell in %s closed", name)
+	}
+	ui.updateContainers()
+}
+
+// execShell attaches the terminal to a TTY exec session running shell in the
+// container until the session ends.
+func (ui *dockerUI) execShell(id, shell string) error {
+	ctx := context.Background()
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("stdin is not a terminal")
+	}
+
+	opts := container.ExecOptions{
+		Cmd:          []string{shell},
+		Env:          []string{"TERM=" + os.Getenv("TERM")},
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if width, height, err := term.GetSize(fd); err == nil {
+		opts.ConsoleSize = &[2]uint{uint(height), uint(width)}
+	}
+
+	exec, err := ui.client().ContainerExecCreate(ctx, id, opts)
+	if err != nil {
+		return err
+	}
+	resp, err := ui.client().ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{Tty: true})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	resize := func() {
+		if width, height, err := term.GetSize(fd); err == nil {
+			ui.client().ContainerExecResize(ctx, exec.ID, container.ResizeOptions{Height: uint(height), Width: uint(width)})
+		}
+	}
+	resize()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	done := make(chan struct{})
+	inputDone := make(chan struct{})
+	go func() {
+		defer close(inputDone)
+		relayInput(resp.Conn, fd, done)
+	}()
+	go func() {
+		for {
+			select {
+			case <-winch:
+				resize()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// The session is over when the shell's output ends
+	_, err = io.Copy(os.Stdout, resp.Reader)
+	close(done)
+	<-inputDone
+
+	if err != nil {
+		return err
+	}
+
+	// 126 and 127 usually mean the shell itself could not be started
+	inspect, err := ui.client().ContainerExecInspect(ctx, exec.ID)
+	if err == nil && (inspect.ExitCode == 126 || inspect.ExitCode == 127) {
+		return fmt.Errorf("exited with status %d, is %s installed in the container?", inspect.ExitCode, shell)
+	}
+	return nil
+}
+
+// relayInput copies keystrokes from fd to dst until done is closed. It polls
+// instead of blocking in read, so no keystroke meant for the UI is swallowed
+// after the shell exits.
+func relayInput(dst io.Writer, fd int, done <-chan struct{}) {
+	buf := make([]byte, 1024)
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		n, err := unix.Poll(fds, 100)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+
+		n, err = unix.Read(fd, buf)
+		if err != nil || n == 0 {
+			return
+		}
+		if _, err := dst.Write(buf[:n]); err != nil {
+			return
+		}
+	}
+}
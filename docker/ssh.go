@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshDialer reaches the daemon of an ssh:// host through
+// `ssh host docker system dial-stdio`, as the docker CLI does, so the remote
+// side needs nothing but sshd and docker. ssh runs in batch mode: a password
+// prompt would garble the UI, so use keys or an agent.
+func sshDialer(host string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid SSH host %q, expected ssh://[user@]host[:port]", host)
+	}
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return nil, fmt.Errorf("ssh is required to connect to %s", host)
+	}
+
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=30", "-T"}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	args = append(args, "--", u.Hostname(), "docker", "system", "dial-stdio")
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		// Not bound to ctx: the connection outlives the dial
+		return newCommandConn(exec.Command("ssh", args...))
+	}, nil
+}
+
+// commandConn is a connection over the stdin and stdout of a command.
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr lockedBuffer
+
+	closeOnce sync.Once
+}
+
+func newCommandConn(cmd *exec.Cmd) (*commandConn, error) {
+	c := &commandConn{cmd: cmd}
+	var err error
+	if c.stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if c.stdout, err = cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	cmd.Stderr = &c.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Read reports what the command printed on stderr when it ends early, which
+// is where ssh explains a refused key or an unknown host.
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF {
+		if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+			return n, fmt.Errorf("%s: %s", c.cmd.Path, msg)
+		}
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// CloseWrite ends the input, which the client does after sending exec input.
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+// Deadlines aren't supported over pipes; the client's contexts bound requests instead.
+func (c *commandConn) SetDeadline(time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(time.Time) error { return nil }
+
+// lockedBuffer collects stderr, which the command writes while Read may look at it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
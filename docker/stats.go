@@ -0,0 +1,337 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/rivo/tview"
+)
+
+const (
+	// statsHistory is how many samples are kept per container for sparklines
+	statsHistory = 60
+	// statsRedrawInterval is how often the stats panel and table columns refresh
+	statsRedrawInterval = time.Second
+)
+
+// statsSample is one reading of a container's resource usage, with I/O
+// converted to per-second rates.
+type statsSample struct {
+	CPU        float64
+	MemUsage   uint64
+	MemLimit   uint64
+	NetRx      float64
+	NetTx      float64
+	BlockRead  float64
+	BlockWrite float64
+	PIDs       uint64
+}
+
+// MemPercent is memory usage as a percentage of the limit.
+func (s statsSample) MemPercent() float64 {
+	if s.MemLimit == 0 {
+		return 0
+	}
+	return float64(s.MemUsage) / float64(s.MemLimit) * 100
+}
+
+// cpuText is the CPU usage as the table shows it.
+func (s statsSample) cpuText() string {
+	return fmt.Sprintf("%.1f%%", s.CPU)
+}
+
+// containerStats is the recent history of one container.
+type containerStats struct {
+	samples []statsSample
+
+	// Totals of the previous reading, to turn counters into rates
+	read                  time.Time
+	netRx, netTx          uint64
+	blockRead, blockWrite uint64
+}
+
+// statsCollector keeps a live stats stream open for every running container.
+type statsCollector struct {
+	cli dockerService
+
+	mu      sync.Mutex
+	streams map[string]context.CancelFunc
+	stats   map[string]*containerStats
+}
+
+func newStatsCollector(cli dockerService) *statsCollector {
+	return &statsCollector{
+		cli:     cli,
+		streams: make(map[string]context.CancelFunc),
+		stats:   make(map[string]*containerStats),
+	}
+}
+
+// watch streams stats for exactly the given containers, starting and stopping
+// streams as containers come and go.
+func (c *statsCollector) watch(ids []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+		if _, ok := c.streams[id]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		c.streams[id] = cancel
+		c.stats[id] = &containerStats{}
+		go c.stream(ctx, id)
+	}
+
+	for id, cancel := range c.streams {
+		if !wanted[id] {
+			cancel()
+			delete(c.streams, id)
+			delete(c.stats, id)
+		}
+	}
+}
+
+func (c *statsCollector) stream(ctx context.Context, id string) {
+	resp, err := c.cli.ContainerStats(ctx, id, true)
+	if err != nil {
+		c.forget(ctx, id)
+		return
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var raw container.StatsResponse
+		if err := decoder.Decode(&raw); err != nil {
+			c.forget(ctx, id)
+			return
+		}
+
+		c.mu.Lock()
+		if history, ok := c.stats[id]; ok {
+			history.add(raw)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// forget drops a container whose stream ended so the next watch restarts it.
+func (c *statsCollector) forget(ctx context.Context, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx.Err() != nil {
+		return // stopped by watch, which already cleaned up
+	}
+	if cancel, ok := c.streams[id]; ok {
+		cancel()
+		delete(c.streams, id)
+	}
+}
+
+// history returns a copy of the samples recorded for a container, oldest
+// first, and whether the container is being watched at all.
+func (c *statsCollector) history(id string) ([]statsSample, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if history, ok := c.stats[id]; ok {
+		return append([]statsSample(nil), history.samples...), true
+	}
+	return nil, false
+}
+
+// latest returns the most recent sample of a container, if any.
+func (c *statsCollector) latest(id string) (statsSample, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if history, ok := c.stats[id]; ok && len(history.samples) > 0 {
+		return history.samples[len(history.samples)-1], true
+	}
+	return statsSample{}, false
+}
+
+func (h *containerStats) add(raw container.StatsResponse) {
+	sample := statsSample{
+		CPU:      calculateCPUPercentUnix(raw.CPUStats, raw.PreCPUStats),
+		MemUsage: memoryUsage(raw.MemoryStats),
+		MemLimit: raw.MemoryStats.Limit,
+		PIDs:     raw.PidsStats.Current,
+	}
+
+	var netRx, netTx uint64
+	for _, network := range raw.Networks {
+		netRx += network.RxBytes
+		netTx += network.TxBytes
+	}
+	var blockRead, blockWrite uint64
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			blockRead += entry.Value
+		case "write":
+			blockWrite += entry.Value
+		}
+	}
+
+	if !h.read.IsZero() {
+		if elapsed := raw.Read.Sub(h.read).Seconds(); elapsed > 0 {
+			sample.NetRx = rate(netRx, h.netRx, elapsed)
+			sample.NetTx = rate(netTx, h.netTx, elapsed)
+			sample.BlockRead = rate(blockRead, h.blockRead, elapsed)
+			sample.BlockWrite = rate(blockWrite, h.blockWrite, elapsed)
+		}
+	}
+	h.read, h.netRx, h.netTx, h.blockRead, h.blockWrite = raw.Read, netRx, netTx, blockRead, blockWrite
+
+	h.samples = append(h.samples, sample)
+	if len(h.samples) > statsHistory {
+		h.samples = h.samples[len(h.samples)-statsHistory:]
+	}
+}
+
+// rate is the per-second change of a counter, zero if it was reset.
+func rate(now, before uint64, seconds float64) float64 {
+	if now < before {
+		return 0
+	}
+	return float64(now-before) / seconds
+}
+
+// memoryUsage excludes the page cache, like `docker stats` does.
+func memoryUsage(m container.MemoryStats) uint64 {
+	cache := m.Stats["inactive_file"]                // cgroup v2
+	if v, ok := m.Stats["total_inactive_file"]; ok { // cgroup v1
+		cache = v
+	}
+	if cache < m.Usage {
+		return m.Usage - cache
+	}
+	return m.Usage
+}
+
+// renderStats refreshes the CPU and MEM columns of the table and the stats
+// panel of the highlighted container.
+func (ui *dockerUI) renderStats() {
+	for row := 1; row < ui.containerList.GetRowCount(); row++ {
+		c := ui.rowAt(row).container
+		if c == nil {
+			continue
+		}
+		cpu, mem := "-", "-"
+		if sample, ok := ui.stats.latest(c.ID); ok {
+			cpu, mem = sample.cpuText(), formatBytes(float64(sample.MemUsage))
+		}
+		ui.containerList.SetCell(row, 4, tview.NewTableCell(cpu).SetAlign(tview.AlignRight))
+		ui.containerList.SetCell(row, 5, tview.NewTableCell(mem).SetAlign(tview.AlignRight))
+	}
+
+	ui.statsView.Clear()
+	id, name, ok := ui.selectedContainer()
+	if !ok {
+		return
+	}
+	ui.statsView.SetTitle("Container Stats: " + name)
+
+	samples, watched := ui.stats.history(id)
+	if !watched {
+		fmt.Fprintln(ui.statsView, "[gray]No stats, the container is not running")
+		return
+	}
+	if len(samples) == 0 {
+		fmt.Fprintln(ui.statsView, "[gray]Waiting for stats...")
+		return
+	}
+
+	_, _, width, _ := ui.statsView.GetInnerRect()
+	series := func(f func(statsSample) float64) []float64 {
+		values := make([]float64, len(samples))
+		for i, sample := range samples {
+			values[i] = f(sample)
+		}
+		return values
+	}
+	last := samples[len(samples)-1]
+
+	fmt.Fprintf(ui.statsView, "[yellow]CPU[-]    %.2f%%\n[green]%s[-]\n", last.CPU,
+		sparkline(series(func(s statsSample) float64 { return s.CPU }), 0, width))
+	fmt.Fprintf(ui.statsView, "[yellow]Memory[-] %s / %s (%.1f%%)\n[green]%s[-]\n",
+		formatBytes(float64(last.MemUsage)), formatBytes(float64(last.MemLimit)), last.MemPercent(),
+		sparkline(series(func(s statsSample) float64 { return s.MemPercent() }), 100, width))
+	fmt.Fprintf(ui.statsView, "[yellow]Net[-]    rx %s/s  tx %s/s\n[green]%s[-]\n",
+		formatBytes(last.NetRx), formatBytes(last.NetTx),
+		sparkline(series(func(s statsSample) float64 { return s.NetRx + s.NetTx }), 0, width))
+	fmt.Fprintf(ui.statsView, "[yellow]Block[-]  r %s/s  w %s/s\n[green]%s[-]\n",
+		formatBytes(last.BlockRead), formatBytes(last.BlockWrite),
+		sparkline(series(func(s statsSample) float64 { return s.BlockRead + s.BlockWrite }), 0, width))
+	fmt.Fprintf(ui.statsView, "[yellow]PIDs[-]   %d\n[green]%s[-]\n", last.PIDs,
+		sparkline(series(func(s statsSample) float64 { return float64(s.PIDs) }), 0, width))
+}
+
+func calculateCPUPercentUnix(v container.CPUStats, pre container.CPUStats) float64 {
+	cpuPercent := 0.0
+	cpuDelta := float64(v.CPUUsage.TotalUsage) - float64(pre.CPUUsage.TotalUsage)
+	systemDelta := float64(v.SystemUsage) - float64(pre.SystemUsage)
+
+	// PercpuUsage is empty on cgroup v2, where OnlineCPUs is always set
+	cpus := float64(v.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(v.CPUUsage.PercpuUsage))
+	}
+
+	if systemDelta > 0.0 && cpuDelta > 0.0 {
+		cpuPercent = (cpuDelta / systemDelta) * cpus * 100.0
+	}
+	return cpuPercent
+}
+
+// sparkBlocks are the bar heights of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the last width values as bars scaled to max, or to the
+// largest value when max is zero.
+func sparkline(values []float64, max float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if max <= 0 {
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if max > 0 {
+			level = int(v / max * float64(len(sparkBlocks)-1))
+		}
+		level = int(math.Min(math.Max(float64(level), 0), float64(len(sparkBlocks)-1)))
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "12.5 MiB".
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
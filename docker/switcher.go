@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showContextPicker lists the Docker contexts to switch between, the current
+// one marked.
+func (ui *dockerUI) showContextPicker() {
+	contexts, err := listContexts()
+	if err != nil {
+		ui.setStatus("[red]%v", err)
+		return
+	}
+
+	previous := ui.app.GetFocus()
+	dismiss := func() {
+		ui.pages.RemovePage("contexts")
+		ui.app.SetFocus(previous)
+	}
+
+	connected := ui.connectedTo()
+	if !slices.ContainsFunc(contexts, func(ctx dockerContext) bool { return ctx.Name == connected.Name }) {
+		contexts = append([]dockerContext{connected}, contexts...) // a --host or :ctx host
+	}
+	current := connected.Name
+	list := tview.NewList().SetSecondaryTextColor(tcell.ColorGray)
+	list.SetBorder(true).SetTitle(" Docker contexts — enter:switch  esc:cancel ")
+	for i, ctx := range contexts {
+		name, details := ctx.Name, ctx.Host
+		if ctx.Description != "" {
+			details += " — " + ctx.Description
+		}
+		if ctx.Name == current {
+			name += " (current)"
+			list.SetCurrentItem(i)
+		}
+		target := ctx.Name
+		list.AddItem(tview.Escape(name), tview.Escape(details), 0, func() {
+			dismiss()
+			if target != current {
+				ui.switchContext(target)
+			}
+		})
+	}
+	list.SetDoneFunc(dismiss)
+
+	height := min(2*len(contexts)+2, 20)
+	layout := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(list, height, 0, true).
+			AddItem(nil, 0, 1, false), 0, 2, true).
+		AddItem(nil, 0, 1, false)
+
+	ui.pages.AddPage("contexts", layout, true, true)
+	ui.app.SetFocus(list)
+}
+
+// switchContext reconnects the UI to the daemon of another Docker context, or
+// of a host given as a URL, keeping the current one if it can't be reached.
+func (ui *dockerUI) switchContext(name string) {
+	target, err := findContext(name)
+	if err != nil && strings.Contains(name, "://") {
+		target, err = hostContext(name), nil
+	}
+	if err != nil {
+		ui.setStatus("[red]%v", err)
+		return
+	}
+	ui.setStatus("[yellow]Connecting to %s (%s)...", target.Name, target.Host)
+
+	go func() {
+		var ping types.Ping
+		cli, err := newContextClient(target)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+			ping, err = cli.Ping(ctx)
+			cancel()
+			if err != nil {
+				cli.Close()
+			}
+		}
+
+		ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ui.setStatus("[red]Failed to connect to %s: %v", target.Name, err)
+				return
+			}
+			ui.logs.stop()
+			ui.stats.watch(nil)
+			ui.stats = newStatsCollector(cli)
+			ui.setClient(cli, target).Close()
+			ui.connState, ui.apiVersion = connected, ping.APIVersion
+			ui.hideUnavailable()
+			ui.renderConnection()
+
+			ui.containers = nil
+			ui.showContainers()
+			ui.watchEvents()
+			ui.setStatus("[green]Switched to %s", describeContext(target))
+		})
+	}()
+}
+
+// describeContext names a context and its host for messages.
+func describeContext(ctx dockerContext) string {
+	if ctx.Name == ctx.Host {
+		return ctx.Host
+	}
+	return fmt.Sprintf("context %s (%s)", ctx.Name, ctx.Host)
+}
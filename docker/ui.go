@@ -1,203 +1,615 @@
 package docker
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
-func RunDockerUI() {
-	app := tview.NewApplication()
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+// dockerUI holds the widgets of the Docker TUI and the client they display.
+type dockerUI struct {
+	app  *tview.Application
+	opts Options
+
+	// cli and the context it connects to are swapped by the context switcher
+	// while requests are in flight
+	cliMu  sync.RWMutex
+	cli    dockerService
+	target dockerContext
+
+	pages          *tview.Pages
+	containerPanel *tview.Flex
+	containerList  *tview.Table
+	search         *tview.InputField
+	statsView      *tview.TextView
+	logs           *logPanel
+	stats          *statsCollector
+	statusBar      *tview.TextView
+	// statusRow holds the status bar and the connection state beside it
+	statusRow      *tview.Flex
+	connectionView *tview.TextView
+	commandInput   *tview.InputField
+	inputOverlay   tview.Primitive
+	// focusBeforeInput gets the focus back when the command input closes
+	focusBeforeInput tview.Primitive
+	// stopEvents ends the event subscription of the current client
+	stopEvents context.CancelFunc
+	// done is closed when the UI exits, ending its background loops
+	done chan struct{}
+
+	// connState is what the last ping of the daemon found; checkConnection
+	// asks for a ping before the next tick
+	connState          connectionState
+	connErr            error
+	apiVersion         string
+	lastCheck          time.Time
+	downSince          time.Time
+	checkConnection    chan struct{}
+	unavailableView    *tview.TextView
+	unavailableOverlay tview.Primitive
+	// focusBeforeError gets the focus back when the error screen closes
+	focusBeforeError tview.Primitive
+	// history holds the commands run so far; historyPos is the one shown while browsing
+	history    []string
+	historyPos int
+
+	// containers is the latest listing; collapsed holds the folded Compose projects
+	containers []types.Container
+	collapsed  map[string]bool
+	// filter is the search text; sortBy and sortDesc order the containers within
+	// their group; showAll includes stopped containers
+	filter   string
+	sortBy   string
+	sortDesc bool
+	showAll  bool
+}
+
+// Options configures the Docker TUI.
+type Options struct {
+	// Shell is started by the shell key, DefaultShell when empty
+	Shell string
+	// Host or Context pick the daemon instead of the docker CLI's current context
+	Host    string
+	Context string
+}
+
+// dockerContext resolves the context to connect to first.
+func (o Options) dockerContext() (dockerContext, error) {
+	switch {
+	case o.Host != "":
+		return hostContext(o.Host), nil
+	case o.Context != "":
+		return findContext(o.Context)
+	default:
+		return currentContext()
+	}
+}
+
+// RunDockerUI runs the Docker TUI until it's quit. An unreachable daemon
+// doesn't stop it: the UI shows why and reconnects when the daemon is back.
+// The error is about the options, such as an unknown context, or the terminal.
+func RunDockerUI(opts Options) error {
+	target, err := opts.dockerContext()
+	if err != nil {
+		return err
+	}
+	cli, err := newContextClient(target)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("can't connect to %s: %w", describeContext(target), err)
+	}
+	defer cli.Close()
+
+	return newDockerUI(cli, target, opts).run()
+}
+
+// run shows the UI until it's quit, following the daemon meanwhile.
+func (ui *dockerUI) run() error {
+	ui.watchEvents()
+	go ui.monitorConnection()
+	go ui.redrawStats()
+	defer func() {
+		close(ui.done)
+		ui.stopEvents()
+		ui.stats.watch(nil)
+		ui.logs.stop()
+	}()
+	return ui.app.Run()
+}
+
+// redrawStats refreshes the usage columns and the stats panel as samples come in.
+func (ui *dockerUI) redrawStats() {
+	ticker := time.NewTicker(statsRedrawInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ui.done:
+			return
+		case <-ticker.C:
+		}
+		ui.app.QueueUpdateDraw(func() {
+			// Usage changes order too when sorted by it
+			if ui.sortBy == "cpu" || ui.sortBy == "mem" {
+				ui.renderContainers()
+			} else {
+				ui.renderStats()
+			}
+		})
 	}
+}
+
+func newDockerUI(cli dockerService, target dockerContext, opts Options) *dockerUI {
+	ui := &dockerUI{app: tview.NewApplication(), cli: cli, target: target, opts: opts,
+		stats: newStatsCollector(cli), collapsed: make(map[string]bool), showAll: true,
+		checkConnection: make(chan struct{}, 1), done: make(chan struct{})}
+
+	ui.containerList = tview.NewTable().SetSelectable(true, false).SetBorders(true)
+
+	ui.search = tview.NewInputField().
+		SetLabel("/").
+		SetFieldBackgroundColor(tcell.ColorBlack).
+		SetChangedFunc(func(text string) {
+			ui.filter = text
+			ui.renderContainers()
+		}).
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEscape {
+				ui.search.SetText("")
+			}
+			if ui.filter == "" {
+				ui.containerPanel.ResizeItem(ui.search, 0, 0)
+			}
+			ui.app.SetFocus(ui.containerList)
+		})
+
+	ui.containerPanel = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(ui.containerList, 0, 1, true).
+		AddItem(ui.search, 0, 0, false)
+	ui.containerPanel.SetTitle("Containers").SetBorder(true)
 
-	containerList := tview.NewTable().SetSelectable(true, false).SetBorders(true)
-	containerList.SetTitle("Containers").SetBorder(true)
+	ui.statsView = tview.NewTextView().SetDynamicColors(true)
+	ui.statsView.SetTitle("Container Stats").SetBorder(true)
 
-	statsView := tview.NewTextView().SetDynamicColors(true)
-	statsView.SetTitle("Container Stats").SetBorder(true)
+	ui.logs = newLogPanel(ui)
 
-	logView := tview.NewTextView().SetDynamicColors(true)
-	logView.SetTitle("Container Logs").SetBorder(true)
+	ui.statusBar = tview.NewTextView().SetDynamicColors(true)
+	ui.connectionView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight)
+	ui.statusRow = tview.NewFlex().
+		AddItem(ui.statusBar, 0, 1, false).
+		AddItem(ui.connectionView, 0, 0, false)
+	ui.renderConnection()
+	ui.newUnavailableScreen()
 
 	helpBar := tview.NewTextView().
 		SetDynamicColors(true).
-		SetText("[::b]=[::-]:refresh  [::b]i[::-]:info  [::b]l[::-]:shell  [::b][RETURN][::-]:logs  [::b]r[::-]:restart  [::b]s[::-]:stop  [::b]:q[::-]:quit")
+		SetText("[::b]=[::-]:refresh  [::b]i[::-]:info  [::b]l[::-]:shell  [::b]enter[::-]:logs  [::b]tab[::-]:focus logs  [::b]space[::-]:fold project  [::b]/[::-]:search  [::b]a[::-]:all/running  [::b]o/O[::-]:sort/reverse  [::b]S[::-]:start  [::b]s[::-]:stop  [::b]r[::-]:restart  [::b]p[::-]:pause/unpause  [::b]K[::-]:kill  [::b]D[::-]:remove  [::b]:ctx[::-]:context  [::b]:help[::-]:commands  [::b]:q[::-]:quit")
 
 	dashboardPage := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewFlex().
-			AddItem(containerList, 0, 3, true).
-			AddItem(statsView, 0, 1, false),
+			AddItem(ui.containerPanel, 0, 3, true).
+			AddItem(ui.statsView, 0, 1, false),
 			0, 1, true).
-		AddItem(logView, 0, 1, false).
+		AddItem(ui.logs.layout, 0, 1, false).
+		AddItem(ui.statusRow, 1, 0, false).
 		AddItem(helpBar, 1, 0, false)
 
-	overlay := tview.NewBox().
-		SetBorder(true).
-		SetTitle("Command Input").
-		SetTitleAlign(tview.AlignLeft)
-
-	commandInput := tview.NewInputField().
+	ui.commandInput = tview.NewInputField().
 		SetLabel(":").
-		SetFieldWidth(2).
+		SetFieldWidth(0).
 		SetFieldBackgroundColor(tcell.ColorBlack)
-
-	overlayFlex := tview.NewFlex().
-		AddItem(nil, 0, 1, false).
-		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
-			AddItem(nil, 0, 1, false).
-			AddItem(tview.NewFlex().AddItem(commandInput, 0, 1, true), 1, 1, false).
-			AddItem(nil, 0, 1, false),
-			3, 1, true,
-		).
-		AddItem(nil, 0, 1, false)
-
-	overlay.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-		overlayFlex.SetRect(x, y, width, height)
-		overlayFlex.Draw(screen)
-		return x, y, width, height
-	})
+	ui.commandInput.SetBorder(true).SetTitle("Command Input").SetTitleAlign(tview.AlignLeft)
+	ui.commandInput.SetInputCapture(ui.handleCommandKey)
 
 	overlayPage := tview.NewFlex().
 		AddItem(nil, 0, 1, false).
 		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
 			AddItem(nil, 0, 1, false).
-			AddItem(tview.NewFlex().AddItem(commandInput, 0, 0, false), 1, 1, false).
-			AddItem(overlay, 3, 1, false).
-			AddItem(nil, 0, 1, false), 40, 1, true).
+			AddItem(ui.commandInput, 3, 0, true).
+			AddItem(nil, 0, 1, false), 0, 2, true).
 		AddItem(nil, 0, 1, false)
 
-	pages := tview.NewPages().
+	ui.pages = tview.NewPages().
 		AddPage("main", dashboardPage, true, true).
 		AddPage("input", overlayPage, true, false)
+	ui.inputOverlay = overlayPage
 
-	commandInput.SetDoneFunc(func(key tcell.Key) {
-		if key == tcell.KeyEnter {
-			command := commandInput.GetText()
-			if command == "q" {
-				app.Stop()
-			}
+	ui.commandInput.SetDoneFunc(func(key tcell.Key) {
+		command := strings.TrimSpace(ui.commandInput.GetText())
+		ui.commandInput.SetText("")
+		ui.pages.HidePage("input")
+		ui.app.SetFocus(ui.focusBeforeInput)
 
-			commandInput.SetText("")
-			pages.HidePage("input")
-			app.SetFocus(containerList)
+		if key == tcell.KeyEnter && command != "" {
+			if n := len(ui.history); n == 0 || ui.history[n-1] != command {
+				ui.history = append(ui.history, command)
+			}
+			ui.runCommand(command)
 		}
 	})
 
-	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape {
-			pages.HidePage("input")
-			app.SetFocus(containerList)
-			return nil
+	ui.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Modals and detail pages have keys of their own
+		switch page, _ := ui.pages.GetFrontPage(); page {
+		case "main", "resources", "input", "unavailable":
+		default:
+			return event
+		}
+		// Text fields handle their own keys, including Escape
+		if _, typing := ui.app.GetFocus().(*tview.InputField); typing {
+			return event
 		}
 		if event.Rune() == ':' {
-			pages.ShowPage("input")
-			app.SetFocus(commandInput)
+			ui.focusBeforeInput = ui.app.GetFocus()
+			ui.historyPos = len(ui.history)
+			ui.pages.ShowPage("input")
+			ui.app.SetFocus(ui.commandInput)
 			return nil
 		}
 		return event
 	})
 
-	updateContainers := func() {
-		containers, err := cli.ContainerList(context.Background(), container.ListOptions{All: true})
-		if err != nil {
-			return
+	ui.containerList.SetInputCapture(ui.handleContainerKey)
+
+	ui.containerList.SetSelectedFunc(func(row int, column int) {
+		entry := ui.rowAt(row)
+		switch {
+		case entry.container != nil:
+			ui.logs.follow(containerName(*entry.container), logTarget{ID: entry.container.ID})
+		case entry.project != nil:
+			ui.logs.follow(entry.project.Name, entry.project.logTargets()...)
 		}
+	})
+	ui.containerList.SetSelectionChangedFunc(func(row int, column int) {
+		ui.renderStats()
+	})
 
-		containerList.Clear()
-		containerList.SetCell(0, 0, tview.NewTableCell("ID").SetTextColor(tcell.ColorYellow))
-		containerList.SetCell(0, 1, tview.NewTableCell("Name").SetTextColor(tcell.ColorYellow))
-		containerList.SetCell(0, 2, tview.NewTableCell("Image").SetTextColor(tcell.ColorYellow))
-		containerList.SetCell(0, 3, tview.NewTableCell("Status").SetTextColor(tcell.ColorYellow))
+	ui.app.SetFocus(ui.containerList)
+	ui.app.SetRoot(ui.pages, true).EnableMouse(true)
+	return ui
+}
 
-		for i, container := range containers {
-			containerList.SetCell(i+1, 0, tview.NewTableCell(container.ID[:12]))
-			containerList.SetCell(i+1, 1, tview.NewTableCell(container.Names[0][1:]))
-			containerList.SetCell(i+1, 2, tview.NewTableCell(container.Image))
-			containerList.SetCell(i+1, 3, tview.NewTableCell(container.Status))
-		}
-	}
+// client returns the client of the current Docker context.
+func (ui *dockerUI) client() dockerService {
+	ui.cliMu.RLock()
+	defer ui.cliMu.RUnlock()
+	return ui.cli
+}
 
-	updateStats := func(containerID string) {
-		stats, err := cli.ContainerStats(context.Background(), containerID, false)
+// connectedTo returns the context the client connects to.
+func (ui *dockerUI) connectedTo() dockerContext {
+	ui.cliMu.RLock()
+	defer ui.cliMu.RUnlock()
+	return ui.target
+}
+
+// setClient switches to another context's client and returns the previous one.
+func (ui *dockerUI) setClient(cli dockerService, target dockerContext) dockerService {
+	ui.cliMu.Lock()
+	defer ui.cliMu.Unlock()
+	previous := ui.cli
+	ui.cli, ui.target = cli, target
+	return previous
+}
+
+// updateContainers relists the containers in the background.
+func (ui *dockerUI) updateContainers() {
+	ui.loadContainers(func(containers []types.Container, err error) {
 		if err != nil {
+			// Most likely the daemon went away, which the error screen explains
+			ui.recheckConnection()
 			return
 		}
-		defer stats.Body.Close()
+		ui.setContainers(containers)
+	})
+}
 
-		var statsJSON types.StatsJSON
-		if err := json.NewDecoder(stats.Body).Decode(&statsJSON); err != nil {
+// refresh relists the containers on request, reporting the outcome.
+func (ui *dockerUI) refresh() {
+	ui.setStatus("[yellow]Refreshing...")
+	ui.loadContainers(func(containers []types.Container, err error) {
+		if err != nil {
+			ui.setStatus("[red]Failed to list containers: %v", err)
+			ui.recheckConnection()
 			return
 		}
+		ui.setContainers(containers)
+		ui.setStatus("[green]Listed %s", plural(len(containers), "container"))
+	})
+}
 
-		cpuPercent := calculateCPUPercentUnix(statsJSON.CPUStats, statsJSON.PreCPUStats)
-		memoryUsage := float64(statsJSON.MemoryStats.Usage) / 1024 / 1024
-		memoryLimit := float64(statsJSON.MemoryStats.Limit) / 1024 / 1024
+// loadContainers lists the containers in the background and hands the result
+// to done on the UI goroutine.
+func (ui *dockerUI) loadContainers(done func([]types.Container, error)) {
+	cli, all := ui.client(), ui.showAll
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		containers, err := cli.ContainerList(ctx, container.ListOptions{All: all})
+		ui.app.QueueUpdateDraw(func() {
+			// A context switch happened meanwhile
+			if cli != ui.client() {
+				return
+			}
+			done(containers, err)
+		})
+	}()
+}
+
+// setContainers replaces the listing, following the stats of the running containers.
+func (ui *dockerUI) setContainers(containers []types.Container) {
+	ui.containers = containers
 
-		statsView.Clear()
-		fmt.Fprintf(statsView, "CPU: %.2f%%\nMemory: %.2f / %.2f MB\n", cpuPercent, memoryUsage, memoryLimit)
+	var running []string
+	for _, c := range containers {
+		if c.State == "running" {
+			running = append(running, c.ID)
+		}
 	}
+	ui.stats.watch(running)
+	ui.renderContainers()
+}
 
-	updateLogs := func(containerID string) {
-		logs, err := cli.ContainerLogs(context.Background(), containerID, container.LogsOptions{
-			ShowStdout: true,
-			ShowStderr: true,
-			Tail:       "10",
-		})
-		if err != nil {
-			return
+// listRow is what a row of the container table shows: a Compose project
+// header or a container.
+type listRow struct {
+	project   *composeProject
+	container *types.Container
+}
+
+// renderContainers fills the table from the latest listing, with Compose
+// projects as collapsible groups above the containers outside Compose.
+// The highlighted container or project stays selected and the scroll position is kept.
+func (ui *dockerUI) renderContainers() {
+	selectedRow, _ := ui.containerList.GetSelection()
+	selected := ui.rowAt(selectedRow)
+	offset, _ := ui.containerList.GetOffset()
+
+	ui.containerList.Clear()
+	for col, header := range []string{"ID", "Name", "Image", "Status", "CPU", "MEM"} {
+		if sortColumns[ui.sortBy] == header {
+			if ui.sortDesc {
+				header += " ▼"
+			} else {
+				header += " ▲"
+			}
+		}
+		ui.containerList.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	shown := 0
+	row := 1
+	addContainer := func(c types.Container, indent string) {
+		cells := containerCells(c)
+		ui.containerList.SetCell(row, 0, tview.NewTableCell(cells[0]).SetReference(listRow{container: &c}))
+		ui.containerList.SetCell(row, 1, tview.NewTableCell(indent+tview.Escape(cells[1])))
+		ui.containerList.SetCell(row, 2, tview.NewTableCell(tview.Escape(cells[2])))
+		ui.containerList.SetCell(row, 3, tview.NewTableCell(tview.Escape(cells[3])))
+		row++
+	}
+
+	projects, loose := groupByProject(ui.containers)
+	for _, p := range projects {
+		p := p
+		p.Containers = ui.visible(p.Containers)
+		if len(p.Containers) == 0 {
+			continue
 		}
-		defer logs.Close()
+		shown += len(p.Containers)
 
-		logView.Clear()
-		scanner := bufio.NewScanner(logs)
-		for scanner.Scan() {
-			fmt.Fprintln(logView, scanner.Text())
+		marker := "▾"
+		if ui.collapsed[p.Name] {
+			marker = "▸"
+		}
+		ui.containerList.SetCell(row, 0, tview.NewTableCell("").SetReference(listRow{project: &p}))
+		ui.containerList.SetCell(row, 1, tview.NewTableCell(marker+" "+tview.Escape(p.Name)).SetTextColor(tcell.ColorTeal).SetAttributes(tcell.AttrBold))
+		ui.containerList.SetCell(row, 2, tview.NewTableCell(plural(len(p.services()), "service")).SetTextColor(tcell.ColorTeal))
+		ui.containerList.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf("%d/%d running", p.running(), len(p.Containers))).SetTextColor(tcell.ColorTeal))
+		row++
+
+		if !ui.collapsed[p.Name] {
+			for _, c := range p.Containers {
+				addContainer(c, "  ")
+			}
 		}
 	}
+	loose = ui.visible(loose)
+	shown += len(loose)
+	for _, c := range loose {
+		addContainer(c, "")
+	}
 
-	containerList.SetSelectedFunc(func(row int, column int) {
-		if row > 0 {
-			containerID := containerList.GetCell(row, 0).Text
-			updateStats(containerID)
-			updateLogs(containerID)
+	title := "Containers"
+	if target := ui.connectedTo(); target.Name != defaultContext {
+		title += " @ " + target.Name
+	}
+	if !ui.showAll {
+		title += " — running only"
+	}
+	if ui.filter != "" {
+		title += fmt.Sprintf(" (%d/%d) — filter: %s", shown, len(ui.containers), ui.filter)
+	}
+	if ui.sortBy != "" {
+		title += " — sort: " + ui.sortBy
+		if ui.sortDesc {
+			title += " (reversed)"
 		}
-	})
+	}
+	ui.containerPanel.SetTitle(tview.Escape(title))
 
-	app.SetFocus(containerList)
-	app.SetRoot(pages, true).EnableMouse(true)
+	ui.containerList.SetOffset(offset, 0)
+	ui.containerList.Select(ui.findRow(selected, selectedRow), 0)
+	ui.renderStats()
+}
 
-	go func() {
-		for {
-			app.QueueUpdateDraw(func() {
-				updateContainers()
-			})
-			time.Sleep(5 * time.Second)
+// findRow returns the row now showing the same container or project as before,
+// or the nearest remaining row if it's gone.
+func (ui *dockerUI) findRow(previous listRow, previousRow int) int {
+	rows := ui.containerList.GetRowCount()
+	for row := 1; row < rows; row++ {
+		entry := ui.rowAt(row)
+		switch {
+		case previous.container != nil && entry.container != nil && entry.container.ID == previous.container.ID,
+			previous.project != nil && entry.project != nil && entry.project.Name == previous.project.Name:
+			return row
 		}
-	}()
+	}
+	return max(1, min(previousRow, rows-1))
+}
+
+// visible returns the containers that pass the filter, in the order set by
+// :sort, or as given when no sort column is chosen.
+func (ui *dockerUI) visible(containers []types.Container) []types.Container {
+	var result []types.Container
+	query := strings.ToLower(ui.filter)
+	for _, c := range containers {
+		fields := []string{containerName(c), c.Image, c.ID, c.Labels[composeProjectLabel], c.Labels[composeServiceLabel]}
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field), query) {
+				result = append(result, c)
+				break
+			}
+		}
+	}
+
+	var less func(a, b types.Container) bool
+	switch ui.sortBy {
+	case "name":
+		less = func(a, b types.Container) bool { return containerName(a) < containerName(b) }
+	case "image":
+		less = func(a, b types.Container) bool { return a.Image < b.Image }
+	case "status":
+		less = func(a, b types.Container) bool { return stateRank[a.State] < stateRank[b.State] }
+	case "created":
+		less = func(a, b types.Container) bool { return a.Created > b.Created }
+	case "cpu":
+		less = func(a, b types.Container) bool {
+			sa, _ := ui.stats.latest(a.ID)
+			sb, _ := ui.stats.latest(b.ID)
+			return sa.CPU > sb.CPU
+		}
+	case "mem":
+		less = func(a, b types.Container) bool {
+			sa, _ := ui.stats.latest(a.ID)
+			sb, _ := ui.stats.latest(b.ID)
+			return sa.MemUsage > sb.MemUsage
+		}
+	}
+	if less != nil {
+		sort.SliceStable(result, func(i, j int) bool {
+			if ui.sortDesc {
+				return less(result[j], result[i])
+			}
+			return less(result[i], result[j])
+		})
+	}
+	return result
+}
+
+// sortOrder is the order the sort key cycles through, and what :sort accepts.
+// Times and usage sort the largest first.
+var sortOrder = []string{"name", "image", "status", "created", "cpu", "mem"}
+
+// sortColumns maps sort keys to the table column that shows the arrow.
+var sortColumns = map[string]string{"name": "Name", "image": "Image", "status": "Status", "cpu": "CPU", "mem": "MEM"}
 
-	if err := app.Run(); err != nil {
-		panic(err)
+// cycleSort moves to the next sort key, back to the default order after the last.
+func (ui *dockerUI) cycleSort() {
+	next := 0
+	if i := slices.Index(sortOrder, ui.sortBy); i >= 0 {
+		next = i + 1
+	}
+	ui.sortBy, ui.sortDesc = "", false
+	if next < len(sortOrder) {
+		ui.sortBy = sortOrder[next]
+	}
+	ui.renderContainers()
+}
+
+// toggleAll switches between all containers and the running ones.
+func (ui *dockerUI) toggleAll() {
+	ui.showAll = !ui.showAll
+	ui.updateContainers()
+	if ui.showAll {
+		ui.setStatus("Showing all containers")
+	} else {
+		ui.setStatus("Showing running containers only")
 	}
 }
 
-func calculateCPUPercentUnix(v container.CPUStats, pre container.CPUStats) float64 {
-	cpuPercent := 0.0
-	cpuDelta := float64(v.CPUUsage.TotalUsage) - float64(pre.CPUUsage.TotalUsage)
-	systemDelta := float64(v.SystemUsage) - float64(pre.SystemUsage)
+// startSearch opens the search field under the container table.
+func (ui *dockerUI) startSearch() {
+	ui.containerPanel.ResizeItem(ui.search, 1, 0)
+	ui.app.SetFocus(ui.search)
+}
+
+// stateRank orders containers by state when sorting by status, live ones first.
+var stateRank = map[string]int{"running": 0, "restarting": 1, "paused": 2, "created": 3, "removing": 4, "exited": 5, "dead": 6}
+
+// containerCells are the ID, name, image and status columns of a container.
+func containerCells(c types.Container) []string {
+	return []string{shortID(c.ID), containerName(c), c.Image, c.Status}
+}
 
-	if systemDelta > 0.0 && cpuDelta > 0.0 {
-		cpuPercent = (cpuDelta / systemDelta) * float64(len(v.CPUUsage.PercpuUsage)) * 100.0
+// rowAt returns what the given table row shows; both fields are nil for the header.
+func (ui *dockerUI) rowAt(row int) listRow {
+	if row <= 0 || row >= ui.containerList.GetRowCount() {
+		return listRow{}
 	}
-	return cpuPercent
+	entry, _ := ui.containerList.GetCell(row, 0).GetReference().(listRow)
+	return entry
+}
+
+// toggleProject folds or unfolds the project of the highlighted row.
+func (ui *dockerUI) toggleProject() {
+	selected, _ := ui.containerList.GetSelection()
+	entry := ui.rowAt(selected)
+
+	name := ""
+	if entry.project != nil {
+		name = entry.project.Name
+	} else if entry.container != nil {
+		name = entry.container.Labels[composeProjectLabel]
+	}
+	if name == "" {
+		return
+	}
+
+	ui.collapsed[name] = !ui.collapsed[name]
+	ui.renderContainers()
+	for row := 1; row < ui.containerList.GetRowCount(); row++ {
+		if p := ui.rowAt(row).project; p != nil && p.Name == name {
+			ui.containerList.Select(row, 0)
+			break
+		}
+	}
+}
+
+// selectedContainer returns the ID and name of the highlighted container.
+func (ui *dockerUI) selectedContainer() (id, name string, ok bool) {
+	row, _ := ui.containerList.GetSelection()
+	if c := ui.rowAt(row).container; c != nil {
+		return c.ID, containerName(*c), true
+	}
+	return "", "", false
+}
+
+// selectedPaused reports whether the highlighted container is paused.
+func (ui *dockerUI) selectedPaused() bool {
+	row, _ := ui.containerList.GetSelection()
+	c := ui.rowAt(row).container
+	return c != nil && c.State == "paused"
+}
+
+// setStatus shows a message in the status bar. It must run on the UI goroutine.
+func (ui *dockerUI) setStatus(format string, args ...interface{}) {
+	ui.statusBar.SetText(fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,335 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	composeShop = composeProjectLabel + "=shop"
+	mib         = 1 << 20
+)
+
+// uiHarness runs the UI against a fake daemon on a simulated screen.
+type uiHarness struct {
+	t      *testing.T
+	fake   *fakeDocker
+	ui     *dockerUI
+	screen tcell.SimulationScreen
+}
+
+func startUI(t *testing.T, fake *fakeDocker) *uiHarness {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	ui := newDockerUI(fake, dockerContext{Name: defaultContext, Host: "unix:///fake/docker.sock"}, Options{})
+	ui.app.SetScreen(screen)
+	screen.SetSize(160, 50)
+
+	done := make(chan error, 1)
+	go func() { done <- ui.run() }()
+	t.Cleanup(func() {
+		ui.app.Stop()
+		if err := <-done; err != nil {
+			t.Errorf("run: %v", err)
+		}
+	})
+	return &uiHarness{t: t, fake: fake, ui: ui, screen: screen}
+}
+
+// onUI runs f on the UI goroutine and waits for it.
+func (h *uiHarness) onUI(f func()) {
+	done := make(chan struct{})
+	h.ui.app.QueueUpdate(func() {
+		f()
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("UI goroutine is stuck")
+	}
+}
+
+// screenText returns what the screen shows, a line per row.
+func (h *uiHarness) screenText() string {
+	var b strings.Builder
+	h.onUI(func() {
+		cells, width, _ := h.screen.GetContents()
+		for i, cell := range cells {
+			if len(cell.Runes) == 0 {
+				b.WriteByte(' ')
+			} else {
+				b.WriteString(string(cell.Runes))
+			}
+			if (i+1)%width == 0 {
+				b.WriteByte('\n')
+			}
+		}
+	})
+	return b.String()
+}
+
+// waitFor polls the screen until it shows all of texts.
+func (h *uiHarness) waitFor(texts ...string) string {
+	h.t.Helper()
+	return h.waitUntil(strings.Join(texts, ", "), func(screen string) bool {
+		for _, text := range texts {
+			if !strings.Contains(screen, text) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// waitForGone polls the screen until it shows none of texts.
+func (h *uiHarness) waitForGone(texts ...string) string {
+	h.t.Helper()
+	return h.waitUntil("no "+strings.Join(texts, ", "), func(screen string) bool {
+		for _, text := range texts {
+			if strings.Contains(screen, text) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (h *uiHarness) waitUntil(what string, ok func(screen string) bool) string {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		screen := h.screenText()
+		if ok(screen) {
+			return screen
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s, the screen shows:\n%s", what, screen)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func (h *uiHarness) press(key tcell.Key) {
+	h.screen.InjectKey(key, 0, tcell.ModNone)
+}
+
+func (h *uiHarness) typeRunes(s string) {
+	for _, r := range s {
+		h.screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+}
+
+// selected returns the name of the highlighted container, empty on a project row.
+func (h *uiHarness) selected() string {
+	var name string
+	h.onUI(func() { _, name, _ = h.ui.selectedContainer() })
+	return name
+}
+
+// selectContainer moves the highlight down from the top to the named container.
+func (h *uiHarness) selectContainer(name string) {
+	h.t.Helper()
+	h.press(tcell.KeyHome)
+	for range 20 {
+		if h.selected() == name {
+			return
+		}
+		var row int
+		h.onUI(func() { row, _ = h.ui.containerList.GetSelection() })
+		h.press(tcell.KeyDown)
+		h.waitUntil("the highlight to move", func(string) bool {
+			var now int
+			h.onUI(func() { now, _ = h.ui.containerList.GetSelection() })
+			return now != row
+		})
+	}
+	h.t.Fatalf("no row shows %s", name)
+}
+
+func shopFake() *fakeDocker {
+	return newFakeDocker(
+		fakeContainer("aaaa00000001", "shop-web-1", "nginx:1.27", "running", composeShop, composeServiceLabel+"=web"),
+		fakeContainer("aaaa00000002", "shop-db-1", "postgres:16", "running", composeShop, composeServiceLabel+"=db"),
+		fakeContainer("bbbb00000001", "old-job", "alpine:3", "exited"),
+	)
+}
+
+func TestContainerListRefresh(t *testing.T) {
+	h := startUI(t, shopFake())
+	h.waitFor("▾ shop", "2 services", "2/2 running", "shop-web-1", "shop-db-1", "old-job", "Exited (0) 3 minutes ago")
+
+	// Events add and remove rows without a relist
+	h.fake.add(fakeContainer("cccc00000001", "worker", "busybox", "running"))
+	h.waitFor("worker", "busybox")
+	if err := h.fake.ContainerRemove(context.Background(), "bbbb00000001", container.RemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	h.waitForGone("old-job")
+
+	// A stop shows up in the project header too
+	if err := h.fake.ContainerStop(context.Background(), "aaaa00000002", container.StopOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	h.waitFor("1/2 running")
+
+	h.typeRunes("a")
+	h.waitFor("Containers — running only", "1/1 running")
+	h.waitForGone("shop-db-1")
+
+	listed := h.fake.listCalls()
+	h.typeRunes("=")
+	h.waitFor("Listed 2 containers")
+	if h.fake.listCalls() <= listed {
+		t.Error("= didn't list the containers again")
+	}
+}
+
+func TestSelectionFollowsContainer(t *testing.T) {
+	h := startUI(t, shopFake())
+	h.waitFor("old-job")
+
+	h.selectContainer("old-job")
+	h.waitFor("Container Stats: old-job")
+
+	// New containers are listed first, pushing old-job down a row
+	h.fake.add(fakeContainer("cccc00000001", "worker", "busybox", "running"))
+	h.waitFor("worker")
+	if got := h.selected(); got != "old-job" {
+		t.Errorf("selected %q after a container was added, want old-job", got)
+	}
+
+	// Folding the project moves the highlight to its header
+	h.selectContainer("shop-db-1")
+	h.typeRunes(" ")
+	h.waitFor("▸ shop")
+	h.waitForGone("shop-web-1", "shop-db-1")
+	var project string
+	h.onUI(func() {
+		row, _ := h.ui.containerList.GetSelection()
+		if p := h.ui.rowAt(row).project; p != nil {
+			project = p.Name
+		}
+	})
+	if project != "shop" {
+		t.Errorf("highlight is on %q after folding, want the shop project", project)
+	}
+
+	h.typeRunes(" ")
+	h.waitFor("▾ shop", "shop-web-1")
+}
+
+func TestStatsRendering(t *testing.T) {
+	fake := shopFake()
+	fake.setUsage("aaaa00000001", fakeUsage{CPU: 25, MemUsage: 64 * mib, MemLimit: 256 * mib})
+	h := startUI(t, fake)
+	h.waitFor("shop-web-1")
+
+	h.selectContainer("shop-web-1")
+	screen := h.waitFor("Container Stats: shop-web-1", "CPU    25.00%", "Memory 64.0 MiB / 256.0 MiB (25.0%)", "PIDs   3")
+	if !strings.Contains(screen, "│25.0%│64.0 MiB│") {
+		t.Errorf("the table doesn't show the usage of shop-web-1:\n%s", screen)
+	}
+
+	fake.setUsage("aaaa00000001", fakeUsage{CPU: 80, MemUsage: 128 * mib, MemLimit: 256 * mib})
+	h.waitFor("CPU    80.00%", "Memory 128.0 MiB / 256.0 MiB (50.0%)")
+
+	h.selectContainer("old-job")
+	h.waitFor("Container Stats: old-job", "No stats, the container is not running")
+}
+
+func TestLogsRendering(t *testing.T) {
+	fake := shopFake()
+	fake.setLogs("aaaa00000001",
+		fakeLogLine{Text: "listening on :80"},
+		fakeLogLine{Text: "GET /health 200"},
+		fakeLogLine{Text: "upstream timed out", Stderr: true})
+	fake.setLogs("aaaa00000002", fakeLogLine{Text: "ready to accept connections"})
+	h := startUI(t, fake)
+	h.waitFor("shop-web-1")
+
+	h.selectContainer("shop-web-1")
+	h.press(tcell.KeyEnter)
+	screen := h.waitFor("Container Logs: shop-web-1", "listening on :80", "GET /health 200", "upstream timed out")
+	if strings.Index(screen, "listening on :80") > strings.Index(screen, "upstream timed out") {
+		t.Errorf("log lines out of order:\n%s", screen)
+	}
+	if fg := h.foreground("upstream timed out"); fg != tcell.ColorRed {
+		t.Errorf("stderr line is drawn in %v, want red", fg)
+	}
+
+	// A project's logs interleave its services, each line prefixed with its service
+	h.press(tcell.KeyHome)
+	h.waitUntil("the project row", func(string) bool { return h.selected() == "" })
+	h.press(tcell.KeyEnter)
+	h.waitFor("Container Logs: shop", "web | listening on :80", "db  | ready to accept connections")
+}
+
+func TestLogsDropReplacedStream(t *testing.T) {
+	fake := shopFake()
+	fake.setLogs("aaaa00000001", fakeLogLine{Text: "listening on :80"})
+	fake.setLogs("aaaa00000002", fakeLogLine{Text: "ready to accept connections"})
+	h := startUI(t, fake)
+	h.waitFor("shop-web-1")
+
+	h.selectContainer("shop-web-1")
+	h.press(tcell.KeyEnter)
+	h.waitFor("Container Logs: shop-web-1", "listening on :80")
+
+	// A line the web stream read just before the switch arrives after it
+	replaced, cancel := context.WithCancel(context.Background())
+	cancel()
+	h.onUI(func() { h.ui.logs.follow("shop-db-1", logTarget{ID: "aaaa00000002"}) })
+	h.ui.logs.add(replaced, logLine{Time: time.Now(), Text: "GET /late 200"})
+	h.waitFor("Container Logs: shop-db-1", "ready to accept connections")
+	time.Sleep(3 * logFlushInterval)
+	if screen := h.screenText(); strings.Contains(screen, "GET /late 200") {
+		t.Errorf("a line of the replaced stream is shown:\n%s", screen)
+	}
+}
+
+func TestDaemonUnavailable(t *testing.T) {
+	fake := shopFake()
+	h := startUI(t, fake)
+	h.waitFor("shop-web-1", "● default · API 1.45")
+
+	fake.setDown(errors.New("dial unix /fake/docker.sock: connect: permission denied"))
+	h.onUI(h.ui.recheckConnection)
+	h.waitFor("Docker unavailable", "Permission denied on the Docker socket unix:///fake/docker.sock.",
+		"sudo usermod -aG docker", "● default unavailable")
+
+	fake.setDown(nil)
+	h.typeRunes("r")
+	h.waitFor("Reconnected to context default", "● default · API 1.45")
+	h.waitForGone("Docker unavailable")
+}
+
+// foreground returns the colour the first character of text is drawn in.
+func (h *uiHarness) foreground(text string) tcell.Color {
+	var color tcell.Color
+	h.onUI(func() {
+		cells, width, height := h.screen.GetContents()
+		for y := 0; y < height; y++ {
+			var line strings.Builder
+			for x := 0; x < width; x++ {
+				if runes := cells[y*width+x].Runes; len(runes) > 0 {
+					line.WriteRune(runes[0])
+				} else {
+					line.WriteRune(' ')
+				}
+			}
+			if x := strings.Index(line.String(), text); x >= 0 {
+				x = len([]rune(line.String()[:x]))
+				color, _, _ = cells[y*width+x].Style.Decompose()
+				return
+			}
+		}
+	})
+	return color
+}
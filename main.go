@@ -2,6 +2,7 @@ package main
 
 import (
     "os"
+    "time"
 
     "github.com/fatih/color"
     "github.com/spf13/cobra"
@@ -19,6 +20,7 @@ func main() {
         color.Red("Error loading config: %v", err)
         os.Exit(1)
     }
+    cmd.Configure(cfg)
 
     var rootCmd = &cobra.Command{
         Use:   "ok",
@@ -37,6 +39,7 @@ func main() {
         createRemoveCommand(),
         createDockerCommand(),
         createKillCommand(),
+        createWaitPortCommand(),
         createVersionCommand(),
     )
 
@@ -105,20 +108,102 @@ func createRemoveCommand() *cobra.Command {
 }
 
 func createDockerCommand() *cobra.Command {
-    return &cobra.Command{
+    dockerCmd := &cobra.Command{
         Use:   "docker",
         Short: "Manage Docker containers",
+        Long:  `Opens an interactive UI to manage Docker containers. It connects to the daemon the docker CLI would use: DOCKER_HOST, else the current Docker context (DOCKER_CONTEXT or "docker context use"), else the local socket. --host and --context pick another one, and :ctx switches while it runs.`,
         Run:   cmd.HandleDocker,
     }
+    dockerCmd.PersistentFlags().StringP("host", "H", "", "daemon to connect to, e.g. unix:///path/docker.sock, tcp://host:2376 or ssh://user@host")
+    dockerCmd.PersistentFlags().StringP("context", "c", "", "Docker context to connect to, from ~/.docker/contexts")
+    dockerCmd.MarkFlagsMutuallyExclusive("host", "context")
+
+    ps := &cobra.Command{
+        Use:   "ps",
+        Short: "List containers, grouped by Compose project",
+        Run:   cmd.HandleDockerPs,
+    }
+    ps.Flags().BoolP("all", "a", false, "include stopped containers")
+
+    logs := &cobra.Command{
+        Use:   "logs <container|project>",
+        Short: "Print the logs of a container or of all services of a Compose project",
+        Run:   cmd.HandleDockerLogs,
+    }
+    logs.Flags().BoolP("follow", "f", false, "keep printing new lines")
+    logs.Flags().BoolP("timestamps", "t", false, "show timestamps")
+    logs.Flags().String("tail", "all", "number of lines to show from the end, or all")
+
+    stop := &cobra.Command{
+        Use:   "stop <pattern>...",
+        Short: "Stop running containers by name, name prefix, ID prefix or glob (e.g. 'shop-*')",
+        Run:   cmd.HandleDockerStop,
+    }
+    stop.Flags().DurationP("timeout", "t", 0, "grace period before the daemon kills the container (default: the container's own)")
+
+    prune := &cobra.Command{
+        Use:   "prune",
+        Short: "Remove stopped containers, dangling images and unused networks",
+        Run:   cmd.HandleDockerPrune,
+    }
+    prune.Flags().Bool("dry-run", false, "only list what would be removed")
+    prune.Flags().Bool("volumes", false, "also remove unused anonymous volumes")
+    prune.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+
+    stats := &cobra.Command{
+        Use:   "stats",
+        Short: "Show live CPU, memory, network, block I/O and PID stats of running containers",
+        Run:   cmd.HandleDockerStats,
+    }
+    stats.Flags().Bool("no-stream", false, "print once instead of refreshing every second")
+
+    dockerCmd.AddCommand(ps, logs, stop, prune, stats)
+    return dockerCmd
 }
 
 func createKillCommand() *cobra.Command {
     cmd := &cobra.Command{
-        Use:   "kill [--port] <port>",
-        Short: "Kill processes listening on a TCP port",
-        Long:  `Finds processes listening on the given TCP port, shows them, and prompts for confirmation before killing. You can specify the port either as a flag (--port 3000) or as a positional argument (3000).`,
+        Use:   "kill [--port] <port>...",
+        Short: "Kill processes listening on a TCP port, or by name, PID or pattern",
+        Long:  `Finds processes listening on the given TCP port, shows them, and prompts for confirmation before killing. You can specify ports either as flags (--port 3000) or as positional arguments (3000 5173 8080-8090); listeners on all of them are shown in one table and confirmed once. Use --name, --pid or --match instead of a port to select processes directly. By default processes get SIGTERM and are sent SIGKILL only if they still hold the port after --timeout.`,
         Run:   cmd.HandleKill,
     }
-    cmd.Flags().IntP("port", "p", 0, "TCP port to free (required)")
+    cmd.Flags().StringSliceP("port", "p", nil, "TCP port or range to free, e.g. 3000 or 8080-8090 (repeatable)")
+    cmd.Flags().StringP("signal", "s", "", "send only this signal (e.g. TERM, KILL, HUP or 9) instead of the graceful TERM-then-KILL sequence")
+    cmd.Flags().DurationP("timeout", "t", 5*time.Second, "how long to wait after SIGTERM before escalating to SIGKILL")
+    cmd.Flags().Bool("tree", false, "also kill all descendants of the listening processes")
+    cmd.Flags().Bool("parent", false, "walk up to the supervising process (e.g. npm, nodemon) and kill its whole tree")
+    cmd.Flags().StringSlice("name", nil, "kill processes with this name instead of by port (repeatable)")
+    cmd.Flags().IntSlice("pid", nil, "kill the process with this PID instead of by port (repeatable)")
+    cmd.Flags().String("match", "", "kill processes whose command line matches this regular expression")
+    cmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+    cmd.Flags().BoolP("force", "f", false, "also kill processes protected by the kill.protected config (sshd, systemd, root-owned, ...)")
+    cmd.Flags().Bool("history", false, "show past kills recorded in ~/.ok/history instead of killing")
+    cmd.Flags().Int("limit", 20, "number of past kills to show with --history (0 for all)")
+    addSocketFlags(cmd)
+    return cmd
+}
+
+func createWaitPortCommand() *cobra.Command {
+    cmd := &cobra.Command{
+        Use:   "wait-port <port> [--free|--listening]",
+        Short: "Wait until a TCP port is listening or free",
+        Long:  `Blocks until something listens on the given TCP port (the default), or until the port is released with --free. With --http <path> it also waits for an HTTP GET of that path on localhost to succeed. Exits with status 1 on timeout.`,
+        Run:   cmd.HandleWaitPort,
+    }
+    cmd.Flags().Bool("free", false, "wait until nothing listens on the port")
+    cmd.Flags().Bool("listening", false, "wait until something listens on the port (default)")
+    cmd.Flags().DurationP("timeout", "t", 30*time.Second, "give up after this long")
+    cmd.Flags().Duration("interval", 250*time.Millisecond, "how often to check the port")
+    cmd.Flags().String("http", "", "also require GET http://localhost:<port><path> to return a non-error status")
+    addSocketFlags(cmd)
     return cmd
 }
+
+// addSocketFlags adds the flags that select which sockets count as using a port.
+func addSocketFlags(cmd *cobra.Command) {
+    cmd.Flags().Bool("udp", false, "look at UDP sockets instead of TCP")
+    cmd.Flags().Bool("all-states", false, "include TCP sockets in any state (e.g. ESTABLISHED) and clients connected to the port, not only listeners")
+    cmd.Flags().BoolP("ipv4", "4", false, "only IPv4 sockets")
+    cmd.Flags().BoolP("ipv6", "6", false, "only IPv6 sockets")
+}
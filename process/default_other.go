@@ -0,0 +1,7 @@
+//go:build !linux
+
+package process
+
+func defaultBackend() Backend {
+	return Lsof{}
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Proto is a transport protocol.
+type Proto string
+
+const (
+	TCP Proto = "TCP"
+	UDP Proto = "UDP"
+)
+
+// Family is an IP address family. The zero value matches both families.
+type Family int
+
+const (
+	AnyFamily Family = 0
+	IPv4      Family = 4
+	IPv6      Family = 6
+)
+
+func (f Family) String() string {
+	switch f {
+	case IPv4:
+		return "IPv4"
+	case IPv6:
+		return "IPv6"
+	}
+	return "IP"
+}
+
+// Addr is one end of a socket.
+type Addr struct {
+	Family Family
+	IP     net.IP // nil or unspecified for a wildcard bind
+	Port   int
+}
+
+// Wildcard reports whether the address is bound to all interfaces.
+func (a Addr) Wildcard() bool {
+	return a.IP == nil || a.IP.IsUnspecified()
+}
+
+// String formats the address the way lsof prints it, e.g. *:3000,
+// 127.0.0.1:3000 or [::1]:3000.
+func (a Addr) String() string {
+	if a.Wildcard() {
+		return fmt.Sprintf("*:%d", a.Port)
+	}
+	return net.JoinHostPort(a.IP.String(), strconv.Itoa(a.Port))
+}
+
+// Socket is a socket matching a Query together with the process holding it.
+// PID is 0 and Command empty when the socket is in use but no process we may
+// inspect holds it, typically one of another user's when not running as root.
+type Socket struct {
+	PID     int
+	Command string
+	User    string
+	Proto   Proto
+	State   string // TCP state such as LISTEN or ESTABLISHED; empty for UDP
+	Local   Addr
+	Remote  *Addr // peer of a connected socket, nil otherwise
+}
+
+// String formats the socket like lsof's NAME column, leaving out the state
+// of listening sockets: *:3000, 127.0.0.1:3000->127.0.0.1:52144 (ESTABLISHED).
+func (s Socket) String() string {
+	name := s.Local.String()
+	if s.Remote != nil {
+		name += "->" + s.Remote.String()
+	}
+	if s.State != "" && s.State != "LISTEN" {
+		name += " (" + s.State + ")"
+	}
+	return name
+}
+
+// Query selects sockets by port, protocol, state and address family.
+type Query struct {
+	Ports []int
+	Proto Proto // TCP when empty
+	// AllStates includes TCP sockets in any state, not only LISTEN, and
+	// sockets whose remote end is on one of Ports (clients connected to
+	// them), matching what `lsof -i :<port>` reports.
+	AllStates bool
+	Family    Family
+}
+
+func (q Query) proto() Proto {
+	if q.Proto == "" {
+		return TCP
+	}
+	return q.Proto
+}
+
+// matches reports whether a socket with the given state and addresses is
+// selected by q. Protocol and family are filtered by the backends.
+func (q Query) matches(state string, local Addr, remote *Addr) bool {
+	if q.proto() == TCP && !q.AllStates && state != "LISTEN" {
+		return false
+	}
+	for _, p := range q.Ports {
+		if local.Port == p || (q.AllStates && remote != nil && remote.Port == p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backend looks up which processes hold sockets on the current platform.
+type Backend interface {
+	// Sockets returns one entry per socket matching q and process holding it.
+	// A process holding several sockets (e.g. IPv4 and IPv6) appears once per
+	// socket. Sockets whose holder is hidden from us are returned with PID 0
+	// where the backend can tell they exist.
+	Sockets(q Query) ([]Socket, error)
+	// Processes returns a snapshot of the process table.
+	Processes() ([]Process, error)
+}
+
+// Default returns the backend best suited to the current platform:
+// /proc parsing on Linux and lsof everywhere else.
+func Default() Backend {
+	return defaultBackend()
+}
@@ -0,0 +1,98 @@
+package process
+
+import (
+	"net"
+	"slices"
+	"testing"
+)
+
+func TestQueryMatches(t *testing.T) {
+	local := func(port int) Addr { return Addr{Family: IPv4, IP: net.IPv4(127, 0, 0, 1), Port: port} }
+	remote := func(port int) *Addr { a := local(port); return &a }
+
+	tests := []struct {
+		name   string
+		q      Query
+		state  string
+		local  Addr
+		remote *Addr
+		want   bool
+	}{
+		{"listener", Query{Ports: []int{3000}}, "LISTEN", local(3000), nil, true},
+		{"listener on another port", Query{Ports: []int{3000}}, "LISTEN", local(3001), nil, false},
+		{"one of several ports", Query{Ports: []int{80, 3000}}, "LISTEN", local(3000), nil, true},
+		{"no ports", Query{}, "LISTEN", local(3000), nil, false},
+		{"accepted connection", Query{Ports: []int{3000}}, "ESTABLISHED", local(3000), remote(54448), false},
+		{"accepted connection, all states", Query{Ports: []int{3000}, AllStates: true}, "ESTABLISHED", local(3000), remote(54448), true},
+		{"client", Query{Ports: []int{3000}}, "ESTABLISHED", local(54448), remote(3000), false},
+		{"client, all states", Query{Ports: []int{3000}, AllStates: true}, "ESTABLISHED", local(54448), remote(3000), true},
+		{"closing, all states", Query{Ports: []int{3000}, AllStates: true}, "CLOSE_WAIT", local(3000), remote(54448), true},
+		{"unrelated connection, all states", Query{Ports: []int{3000}, AllStates: true}, "ESTABLISHED", local(54448), remote(443), false},
+		{"UDP ignores the state", Query{Ports: []int{5353}, Proto: UDP}, "", local(5353), nil, true},
+		{"UDP client", Query{Ports: []int{53}, Proto: UDP}, "", local(40000), remote(53), false},
+		{"UDP client, all states", Query{Ports: []int{53}, Proto: UDP, AllStates: true}, "", local(40000), remote(53), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.matches(tt.state, tt.local, tt.remote); got != tt.want {
+				t.Errorf("%+v matches %s %s->%v = %v, want %v", tt.q, tt.state, tt.local, tt.remote, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestProcFSSocketsFlags runs the --udp, --ipv4/--ipv6 and --all-states
+// combinations against the sockets of fakeProc.
+func TestProcFSSocketsFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"IPv4 only", Query{Ports: []int{3000}, Family: IPv4}, []string{
+			"100 node TCP IPv4 127.0.0.1:3000",
+		}},
+		{"IPv6 only", Query{Ports: []int{3000, 8080}, Family: IPv6}, []string{
+			"100 node TCP IPv6 *:3000",
+			"300 dns TCP IPv6 [::1]:8080",
+		}},
+		{"all states", Query{Ports: []int{3000}, AllStates: true}, []string{
+			"100 node TCP IPv6 *:3000",
+			"100 node TCP IPv4 127.0.0.1:3000",
+			"100 node TCP IPv4 127.0.0.1:3000->127.0.0.1:54448 (ESTABLISHED)",
+			"200 curl TCP IPv4 127.0.0.1:54448->127.0.0.1:3000 (ESTABLISHED)",
+		}},
+		{"all states, IPv4 only", Query{Ports: []int{54448}, AllStates: true, Family: IPv4}, []string{
+			"100 node TCP IPv4 127.0.0.1:3000->127.0.0.1:54448 (ESTABLISHED)",
+			"200 curl TCP IPv4 127.0.0.1:54448->127.0.0.1:3000 (ESTABLISHED)",
+		}},
+		{"all states, IPv6 only", Query{Ports: []int{3000}, AllStates: true, Family: IPv6}, []string{
+			"100 node TCP IPv6 *:3000",
+		}},
+		{"UDP", Query{Ports: []int{5353}, Proto: UDP}, []string{
+			"300 dns UDP IPv4 *:5353",
+		}},
+		{"UDP, all states", Query{Ports: []int{5353}, Proto: UDP, AllStates: true}, []string{
+			"300 dns UDP IPv4 *:5353",
+		}},
+		{"UDP leaves TCP out", Query{Ports: []int{3000}, Proto: UDP}, nil},
+		{"UDP, IPv6 table missing", Query{Ports: []int{5353}, Proto: UDP, Family: IPv6}, nil},
+	}
+	p := fakeProc(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socks, err := p.Sockets(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(socks); !slices.Equal(got, tt.want) {
+				t.Errorf("Sockets(%+v):\n got %q\nwant %q", tt.q, got, tt.want)
+			}
+			for _, s := range socks {
+				if s.Proto == UDP && s.State != "" {
+					t.Errorf("UDP socket %s has state %q", s, s.State)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/antick/ok/utils"
+)
+
+// Lsof looks up sockets by running lsof(8) and processes by running ps(1).
+// It is the default on macOS.
+type Lsof struct{}
+
+func (Lsof) Sockets(q Query) ([]Socket, error) {
+	if len(q.Ports) == 0 {
+		return nil, nil
+	}
+	// -i[46][proto]:ports, where lsof accepts the same comma-separated list of
+	// ports and ranges that FormatPorts produces
+	spec := "-i"
+	if q.Family != AnyFamily {
+		spec += strconv.Itoa(int(q.Family))
+	}
+	spec += string(q.proto()) + ":" + utils.FormatPorts(q.Ports)
+	args := []string{"-nP", spec}
+	if q.proto() == TCP && !q.AllStates {
+		args = append(args, "-sTCP:LISTEN")
+	}
+
+	c := exec.Command("lsof", args...)
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil && stdout.Len() == 0 {
+		// lsof exits with status 1 and prints nothing when no files match
+		var exitErr *exec.ExitError
+		msg := strings.TrimSpace(stderr.String())
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && msg == "" {
+			return nil, nil
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("error running lsof: %v: %s", err, msg)
+		}
+		return nil, fmt.Errorf("error running lsof: %w", err)
+	}
+
+	var res []Socket
+	for _, sock := range parseLsof(stdout.String()) {
+		if q.matches(sock.State, sock.Local, sock.Remote) {
+			res = append(res, sock)
+		}
+	}
+	return res, nil
+}
+
+// parseLsof parses `lsof -nP -i` output, whose columns are
+// COMMAND PID USER FD TYPE DEVICE SIZE/OFF NODE NAME [(STATE)].
+func parseLsof(out string) []Socket {
+	var res []Socket
+	for i, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 && strings.HasPrefix(strings.ToUpper(line), "COMMAND") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		family := IPv4
+		if fields[4] == "IPv6" {
+			family = IPv6
+		}
+
+		sock := Socket{
+			PID:     pid,
+			Command: fields[0],
+			User:    fields[2],
+			Proto:   Proto(fields[7]),
+		}
+		if last := fields[len(fields)-1]; len(fields) > 9 && strings.HasPrefix(last, "(") {
+			sock.State = strings.Trim(last, "()")
+		}
+		local, remote, connected := strings.Cut(fields[8], "->")
+		if sock.Local, err = parseLsofAddr(local, family); err != nil {
+			continue
+		}
+		if connected {
+			r, err := parseLsofAddr(remote, family)
+			if err != nil {
+				continue
+			}
+			sock.Remote = &r
+		}
+		res = append(res, sock)
+	}
+	return res
+}
+
+// parseLsofAddr parses an address such as *:3000, 127.0.0.1:3000 or [::1]:3000.
+func parseLsofAddr(s string, family Family) (Addr, error) {
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		return Addr{}, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return Addr{}, fmt.Errorf("malformed port in %q", s)
+	}
+	addr := Addr{Family: family, Port: port}
+	if host != "*" {
+		if addr.IP = net.ParseIP(host); addr.IP == nil {
+			return Addr{}, fmt.Errorf("malformed address %q", s)
+		}
+	}
+	return addr, nil
+}
+
+func (Lsof) Processes() ([]Process, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,pgid=,user=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running ps: %w", err)
+	}
+
+	var res []Process
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		var ids [3]int
+		for i := range ids {
+			if ids[i], err = strconv.Atoi(fields[i]); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			continue
+		}
+		// comm is the executable path on macOS and may contain spaces
+		comm := strings.Join(fields[4:], " ")
+		res = append(res, Process{
+			PID:     ids[0],
+			PPID:    ids[1],
+			PGID:    ids[2],
+			User:    fields[3],
+			Command: filepath.Base(comm),
+		})
+	}
+
+	// Command lines need a second pass because comm and args both contain spaces
+	out, err = exec.Command("ps", "-axo", "pid=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running ps: %w", err)
+	}
+	args := map[int]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		pidStr, rest, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if pid, err := strconv.Atoi(pidStr); ok && err == nil {
+			args[pid] = strings.TrimSpace(rest)
+		}
+	}
+	for i := range res {
+		res[i].Args = args[res[i].PID]
+	}
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package process
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseLsof(t *testing.T) {
+	out := `COMMAND     PID   USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+node      12345  alice   23u  IPv4 0x9c1d2e3f4a5b6c7d      0t0  TCP *:3000 (LISTEN)
+node      12345  alice   24u  IPv6 0x9c1d2e3f4a5b6c7e      0t0  TCP [::1]:3000 (LISTEN)
+curl      12400  alice    5u  IPv4 0x9c1d2e3f4a5b6c7f      0t0  TCP 127.0.0.1:54448->127.0.0.1:3000 (ESTABLISHED)
+mDNSRespo   301 _mdnsresponder 8u IPv4 0x9c1d2e3f4a5b6c80 0t0  UDP *:5353
+Google\x20 999  alice   30u  IPv6 0x9c1d2e3f4a5b6c81      0t0  UDP [::]:5353
+broken      abc  alice   3u   IPv4 0x9c1d2e3f4a5b6c82      0t0  TCP *:3000 (LISTEN)
+short line
+`
+	want := []string{
+		"12345 node TCP IPv4 *:3000",
+		"12345 node TCP IPv6 [::1]:3000",
+		"12400 curl TCP IPv4 127.0.0.1:54448->127.0.0.1:3000 (ESTABLISHED)",
+		"301 mDNSRespo UDP IPv4 *:5353",
+		`999 Google\x20 UDP IPv6 *:5353`,
+	}
+	socks := parseLsof(out)
+	if got := describe(socks); !slices.Equal(got, want) {
+		t.Errorf("parseLsof:\n got %q\nwant %q", got, want)
+	}
+	if len(socks) > 0 && (socks[0].User != "alice" || socks[0].State != "LISTEN") {
+		t.Errorf("first socket = %+v, want alice's listener", socks[0])
+	}
+	if len(socks) > 3 && socks[3].State != "" {
+		t.Errorf("UDP socket has state %q", socks[3].State)
+	}
+}
+
+func TestParseLsofAddr(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "*:3000", want: "*:3000"},
+		{in: "127.0.0.1:3000", want: "127.0.0.1:3000"},
+		{in: "[::1]:3000", want: "[::1]:3000"},
+		{in: "[::]:3000", want: "*:3000"},
+		{in: "localhost:3000", err: true},
+		{in: "*:http", err: true},
+		{in: "3000", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			addr, err := parseLsofAddr(tt.in, IPv4)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseLsofAddr(%q) = %s, want an error", tt.in, addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLsofAddr(%q): %v", tt.in, err)
+			}
+			if got := addr.String(); got != tt.want {
+				t.Errorf("parseLsofAddr(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
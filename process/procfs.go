@@ -0,0 +1,316 @@
+package process
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProcFS looks up sockets by reading the Linux /proc filesystem directly,
+// so it works in slim containers where lsof is not installed.
+type ProcFS struct {
+	Root string // procfs mount point, usually /proc
+}
+
+// tcpStates maps the hex state column of /proc/net/tcp to the names used by
+// lsof (see include/net/tcp_states.h).
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+func (p ProcFS) Sockets(q Query) ([]Socket, error) {
+	files := map[Family]string{IPv4: "tcp", IPv6: "tcp6"}
+	if q.proto() == UDP {
+		files = map[Family]string{IPv4: "udp", IPv6: "udp6"}
+	}
+
+	sockets := map[uint64]Socket{}
+	uids := map[uint64]string{}
+	for _, family := range []Family{IPv4, IPv6} {
+		if q.Family != AnyFamily && q.Family != family {
+			continue
+		}
+		path := filepath.Join(p.Root, "net", files[family])
+		rows, err := readNetFile(path, family)
+		if err != nil {
+			// The IPv6 tables are missing when IPv6 is disabled in the kernel
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, row := range rows {
+			sock := Socket{Proto: q.proto(), Local: row.local}
+			// Unconnected sockets have an all-zero remote address
+			if row.remote.Port != 0 {
+				remote := row.remote
+				sock.Remote = &remote
+			}
+			if sock.Proto == TCP {
+				sock.State = tcpStates[row.state]
+			}
+			// Sockets in TIME_WAIT and the like have no inode and no owner
+			if row.inode != 0 && q.matches(sock.State, sock.Local, sock.Remote) {
+				sockets[row.inode] = sock
+				uids[row.inode] = row.uid
+			}
+		}
+	}
+	if len(sockets) == 0 {
+		return nil, nil
+	}
+
+	owners, err := p.socketOwners(sockets)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Socket
+	for inode, sock := range sockets {
+		sock.User = lookupUser(uids[inode])
+		if len(owners[inode]) == 0 {
+			// Held by a process we can't inspect; the port is in use all the same
+			res = append(res, sock)
+			continue
+		}
+		for _, pid := range owners[inode] {
+			sock.PID = pid
+			sock.Command = p.command(pid)
+			res = append(res, sock)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].PID != res[j].PID {
+			return res[i].PID < res[j].PID
+		}
+		if res[i].Local.Port != res[j].Local.Port {
+			return res[i].Local.Port < res[j].Local.Port
+		}
+		return res[i].String() < res[j].String()
+	})
+	return res, nil
+}
+
+// socketOwners maps each wanted socket inode to the PIDs holding a file
+// descriptor for it. Processes we are not allowed to inspect are skipped,
+// mirroring what lsof reports when run without privileges.
+func (p ProcFS) socketOwners(wanted map[uint64]Socket) (map[uint64][]int, error) {
+	entries, err := os.ReadDir(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", p.Root, err)
+	}
+
+	owners := map[uint64][]int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(p.Root, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, ok := wanted[inode]; ok && !containsInt(owners[inode], pid) {
+				owners[inode] = append(owners[inode], pid)
+			}
+		}
+	}
+	return owners, nil
+}
+
+func (p ProcFS) Processes() ([]Process, error) {
+	entries, err := os.ReadDir(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", p.Root, err)
+	}
+
+	var res []Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes may exit while we walk the table; skip them
+		proc, err := p.process(pid)
+		if err != nil {
+			continue
+		}
+		res = append(res, proc)
+	}
+	return res, nil
+}
+
+// process reads a single process from /proc/<pid>/{stat,status,cmdline}.
+func (p ProcFS) process(pid int) (Process, error) {
+	dir := filepath.Join(p.Root, strconv.Itoa(pid))
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return Process{}, err
+	}
+	// Format: pid (comm) state ppid pgrp ... where comm may contain spaces
+	open, end := strings.IndexByte(string(stat), '('), strings.LastIndexByte(string(stat), ')')
+	if open < 0 || end < open {
+		return Process{}, fmt.Errorf("malformed %s/stat", dir)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 3 {
+		return Process{}, fmt.Errorf("malformed %s/stat", dir)
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	pgid, _ := strconv.Atoi(fields[2])
+
+	uid := "?"
+	if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+				if f := strings.Fields(rest); len(f) > 0 {
+					uid = f[0]
+				}
+				break
+			}
+		}
+	}
+
+	comm := string(stat[open+1 : end])
+	args := comm
+	// Kernel threads have an empty cmdline; fall back to the name like ps does
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil && len(cmdline) > 0 {
+		args = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+
+	return Process{
+		PID:     pid,
+		PPID:    ppid,
+		PGID:    pgid,
+		User:    lookupUser(uid),
+		Command: comm,
+		Args:    args,
+	}, nil
+}
+
+func (p ProcFS) command(pid int) string {
+	comm, err := os.ReadFile(filepath.Join(p.Root, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// netRow is the subset of a /proc/net/{tcp,udp}{,6} row we care about.
+type netRow struct {
+	local  Addr
+	remote Addr
+	state  string
+	uid    string
+	inode  uint64
+}
+
+func readNetFile(path string, family Family) ([]netRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []netRow
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		local, err := parseHexAddr(fields[1], family)
+		if err != nil {
+			continue
+		}
+		remote, err := parseHexAddr(fields[2], family)
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		// Sockets in TIME_WAIT have no inode and belong to no process
+		if err != nil || inode == 0 {
+			continue
+		}
+		rows = append(rows, netRow{local: local, remote: remote, state: fields[3], uid: fields[7], inode: inode})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// parseHexAddr decodes an address like "0100007F:0BB8". The kernel prints the
+// IP as native-endian 32-bit words, which is little-endian on every platform we
+// support, so each 4-byte group is reversed.
+func parseHexAddr(s string, family Family) (Addr, error) {
+	host, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return Addr{}, fmt.Errorf("malformed address %q", s)
+	}
+	raw, err := hex.DecodeString(host)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return Addr{}, fmt.Errorf("malformed address %q", s)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return Addr{}, fmt.Errorf("malformed port in %q", s)
+	}
+	return Addr{Family: family, IP: net.IP(raw), Port: int(port)}, nil
+}
+
+var userNames = map[string]string{}
+
+func lookupUser(uid string) string {
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const netHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+// netRowText formats a row of /proc/net/{tcp,udp}{,6}.
+func netRowText(sl int, local, remote, state, uid string, inode uint64) string {
+	return fmt.Sprintf("%4d: %s %s %s 00000000:00000000 00:00000000 00000000 %5s        0 %d 1 0000000000000000 100 0 0 10 0\n",
+		sl, local, remote, state, uid, inode)
+}
+
+// fakeProc builds a /proc with these sockets:
+//
+//	tcp   127.0.0.1:3000 LISTEN                       inode 1111, pid 100 node
+//	tcp   127.0.0.1:3000->127.0.0.1:54448 ESTABLISHED inode 2222, pid 100 node
+//	tcp   127.0.0.1:54448->127.0.0.1:3000 ESTABLISHED inode 3333, pid 200 curl
+//	tcp   *:5432 LISTEN                               inode 4444, root, no visible owner
+//	tcp   127.0.0.1:3000->127.0.0.1:54449 TIME_WAIT   inode 0
+//	tcp6  *:3000 LISTEN                               inode 5555, pid 100 node
+//	tcp6  [::1]:8080 LISTEN                           inode 6666, pid 300 dns
+//	udp   *:5353                                      inode 7777, pid 300 dns
+//
+// udp6 is missing, as when IPv6 is disabled in the kernel.
+func fakeProc(t *testing.T) ProcFS {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"net/tcp": netHeader +
+			netRowText(0, "0100007F:0BB8", "00000000:0000", "0A", "1000", 1111) +
+			netRowText(1, "0100007F:0BB8", "0100007F:D4B0", "01", "1000", 2222) +
+			netRowText(2, "0100007F:D4B0", "0100007F:0BB8", "01", "1000", 3333) +
+			netRowText(3, "00000000:1538", "00000000:0000", "0A", "0", 4444) +
+			netRowText(4, "0100007F:0BB8", "0100007F:D4B1", "06", "0", 0),
+		"net/tcp6": netHeader +
+			netRowText(0, "00000000000000000000000000000000:0BB8", "00000000000000000000000000000000:0000", "0A", "1000", 5555) +
+			netRowText(1, "00000000000000000000000001000000:1F90", "00000000000000000000000000000000:0000", "0A", "1000", 6666),
+		"net/udp": netHeader +
+			netRowText(0, "00000000:14E9", "00000000:0000", "07", "1000", 7777),
+		"100/comm":  "node\n",
+		"200/comm":  "curl\n",
+		"300/comm":  "dns\n",
+		"self/comm": "ok\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fds := map[string]string{
+		"100/fd/0": "/dev/null",
+		"100/fd/3": "socket:[1111]",
+		"100/fd/4": "socket:[2222]",
+		"100/fd/5": "socket:[5555]",
+		"200/fd/3": "socket:[3333]",
+		"300/fd/3": "socket:[6666]",
+		"300/fd/4": "socket:[7777]",
+		"300/fd/5": "pipe:[9999]",
+	}
+	for name, target := range fds {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ProcFS{Root: root}
+}
+
+// describe formats sockets compactly for comparison.
+func describe(socks []Socket) []string {
+	var res []string
+	for _, s := range socks {
+		res = append(res, fmt.Sprintf("%d %s %s %s %s", s.PID, s.Command, s.Proto, s.Local.Family, s))
+	}
+	return res
+}
+
+func TestParseHexAddr(t *testing.T) {
+	tests := []struct {
+		in     string
+		family Family
+		want   string
+		err    bool
+	}{
+		{in: "0100007F:0BB8", family: IPv4, want: "127.0.0.1:3000"},
+		{in: "00000000:1F90", family: IPv4, want: "*:8080"},
+		{in: "0101A8C0:0035", family: IPv4, want: "192.168.1.1:53"},
+		{in: "00000000000000000000000001000000:0BB8", family: IPv6, want: "[::1]:3000"},
+		{in: "00000000000000000000000000000000:0016", family: IPv6, want: "*:22"},
+		{in: "0000000000000000FFFF00000100007F:0BB8", family: IPv6, want: "127.0.0.1:3000"},
+		{in: "000080FE00000000FF005452FE123456:01BB", family: IPv6, want: "[fe80::5254:ff:5634:12fe]:443"},
+		{in: "0100007F", family: IPv4, err: true},
+		{in: "0100007:0BB8", family: IPv4, err: true},
+		{in: "0100007F00:0BB8", family: IPv4, err: true},
+		{in: "0100007F:10000", family: IPv4, err: true},
+		{in: "ZZ00007F:0BB8", family: IPv4, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			addr, err := parseHexAddr(tt.in, tt.family)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseHexAddr(%q) = %s, want an error", tt.in, addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHexAddr(%q): %v", tt.in, err)
+			}
+			if got := addr.String(); got != tt.want || addr.Family != tt.family {
+				t.Errorf("parseHexAddr(%q) = %s (%s), want %s (%s)", tt.in, got, addr.Family, tt.want, tt.family)
+			}
+		})
+	}
+}
+
+func TestReadNetFile(t *testing.T) {
+	p := fakeProc(t)
+	rows, err := readNetFile(filepath.Join(p.Root, "net", "tcp"), IPv4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, row := range rows {
+		got = append(got, fmt.Sprintf("%s->%s %s uid=%s inode=%d", row.local, row.remote, row.state, row.uid, row.inode))
+	}
+	// The TIME_WAIT row has no inode and is left out
+	want := []string{
+		"127.0.0.1:3000->*:0 0A uid=1000 inode=1111",
+		"127.0.0.1:3000->127.0.0.1:54448 01 uid=1000 inode=2222",
+		"127.0.0.1:54448->127.0.0.1:3000 01 uid=1000 inode=3333",
+		"*:5432->*:0 0A uid=0 inode=4444",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("readNetFile rows:\n got %q\nwant %q", got, want)
+	}
+
+	if _, err := readNetFile(filepath.Join(p.Root, "net", "udp6"), IPv6); !os.IsNotExist(err) {
+		t.Errorf("reading a missing table: %v, want a not-exist error", err)
+	}
+}
+
+func TestProcFSSocketOwners(t *testing.T) {
+	p := fakeProc(t)
+	owners, err := p.socketOwners(map[uint64]Socket{1111: {}, 3333: {}, 4444: {}, 5555: {}, 7777: {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint64][]int{1111: {100}, 3333: {200}, 5555: {100}, 7777: {300}}
+	if len(owners) != len(want) {
+		t.Errorf("socketOwners = %v, want %v", owners, want)
+	}
+	for inode, pids := range want {
+		if !slices.Equal(owners[inode], pids) {
+			t.Errorf("inode %d is held by %v, want %v", inode, owners[inode], pids)
+		}
+	}
+}
+
+func TestProcFSSockets(t *testing.T) {
+	p := fakeProc(t)
+	socks, err := p.Sockets(Query{Ports: []int{3000, 5432}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The root listener on 5432 has no visible owner but is still reported
+	want := []string{
+		"0  TCP IPv4 *:5432",
+		"100 node TCP IPv6 *:3000",
+		"100 node TCP IPv4 127.0.0.1:3000",
+	}
+	if got := describe(socks); !slices.Equal(got, want) {
+		t.Fatalf("Sockets:\n got %q\nwant %q", got, want)
+	}
+	if hidden := socks[0]; hidden.User != lookupUser("0") || !hidden.Local.Wildcard() {
+		t.Errorf("hidden socket = %+v, want one of uid 0 bound to all interfaces", hidden)
+	}
+}
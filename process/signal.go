@@ -0,0 +1,77 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var signalsByName = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+	"STOP": syscall.SIGSTOP,
+	"CONT": syscall.SIGCONT,
+}
+
+// ParseSignal accepts a signal name with or without the SIG prefix
+// (case-insensitive, e.g. "term", "SIGKILL") or a signal number ("9").
+func ParseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "SIG")
+	if sig, ok := signalsByName[name]; ok {
+		return sig, nil
+	}
+	names := make([]string, 0, len(signalsByName))
+	for n := range signalsByName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return 0, fmt.Errorf("unknown signal %q (expected a number or one of %s)", s, strings.Join(names, ", "))
+}
+
+// SignalName returns the conventional name of sig, e.g. "SIGTERM".
+func SignalName(sig syscall.Signal) string {
+	for name, s := range signalsByName {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
+
+// Alive reports whether a process with the given PID still exists.
+// Zombies that have exited but not yet been reaped count as gone.
+func Alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	// EPERM means the process exists but belongs to someone else
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	return !zombie(pid)
+}
+
+// zombie reports whether /proc shows pid as exited but unreaped. Without
+// /proc (e.g. on macOS) it always returns false.
+func zombie(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	// The command name is parenthesised and may contain spaces, so the
+	// state is the first field after the last ')'.
+	rest := stat[bytes.LastIndexByte(stat, ')')+1:]
+	fields := strings.Fields(string(rest))
+	return len(fields) > 0 && fields[0] == "Z"
+}
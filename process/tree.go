@@ -0,0 +1,91 @@
+package process
+
+import "sort"
+
+// Process is one entry of the system process table.
+type Process struct {
+	PID     int
+	PPID    int
+	PGID    int // process group; a shell puts each job in its own group
+	User    string
+	Command string
+	Args    string // full command line, space separated
+}
+
+// Tree indexes a process table by PID and by parent.
+type Tree struct {
+	byPID    map[int]Process
+	children map[int][]int
+}
+
+// NewTree builds a Tree from a snapshot of the process table.
+func NewTree(procs []Process) *Tree {
+	t := &Tree{byPID: map[int]Process{}, children: map[int][]int{}}
+	for _, p := range procs {
+		t.byPID[p.PID] = p
+		if p.PPID != p.PID {
+			t.children[p.PPID] = append(t.children[p.PPID], p.PID)
+		}
+	}
+	for _, kids := range t.children {
+		sort.Ints(kids)
+	}
+	return t
+}
+
+// Get returns the process with the given PID.
+func (t *Tree) Get(pid int) (Process, bool) {
+	p, ok := t.byPID[pid]
+	return p, ok
+}
+
+// Descendants returns every process below pid in depth-first order, so each
+// parent comes before its children. depth holds the nesting level of each
+// entry, starting at 1 for direct children.
+func (t *Tree) Descendants(pid int) (procs []Process, depth []int) {
+	var walk func(pid, level int)
+	walk = func(pid, level int) {
+		for _, child := range t.children[pid] {
+			procs = append(procs, t.byPID[child])
+			depth = append(depth, level)
+			walk(child, level+1)
+		}
+	}
+	walk(pid, 1)
+	return procs, depth
+}
+
+// IsAncestor reports whether ancestor is above pid in the tree.
+func (t *Tree) IsAncestor(ancestor, pid int) bool {
+	for p, ok := t.byPID[pid]; ok && p.PPID != p.PID; p, ok = t.byPID[p.PPID] {
+		if p.PPID == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Supervisor walks up from pid to the topmost ancestor in the same process
+// group. For `npm run dev` started from a shell this is npm itself, since the
+// shell gives each job its own group and children inherit it. PID 1 is never
+// returned; a process that leads its own group is its own supervisor.
+//
+// The walk stops below caller and its ancestors. Without job control (in
+// scripts, Makefiles or CI) everything shares the group of the script's shell,
+// and the walk would otherwise climb to the shell that is running caller.
+func (t *Tree) Supervisor(pid, caller int) Process {
+	cur, ok := t.byPID[pid]
+	if !ok {
+		return Process{PID: pid}
+	}
+	for {
+		parent, ok := t.byPID[cur.PPID]
+		if !ok || parent.PID <= 1 || parent.PID == cur.PID || parent.PGID != cur.PGID {
+			return cur
+		}
+		if parent.PID == caller || t.IsAncestor(parent.PID, caller) {
+			return cur
+		}
+		cur = parent
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// CopyToClipboard puts text on the system clipboard. Without a clipboard tool
+// it falls back to the OSC 52 escape sequence, which most terminals (including
+// over SSH) turn into a clipboard write.
+func CopyToClipboard(text string) error {
+	for _, tool := range clipboardTools() {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("error copying to clipboard: %s", output)
+		}
+		return nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("no clipboard tool found and no terminal to write to")
+	}
+	defer tty.Close()
+	_, err = fmt.Fprintf(tty, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+func clipboardTools() [][]string {
+	if runtime.GOOS == "darwin" {
+		return [][]string{{"pbcopy"}}
+	}
+	var tools [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		tools = append(tools, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	return tools
+}
@@ -2,6 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // ParseSourceAndDestination parses the command arguments to extract source and destination.
@@ -20,3 +23,68 @@ func ParseSourceAndDestination(args []string) (string, string, error) {
 
 	return "", "", fmt.Errorf("invalid command format")
 }
+
+// ParsePorts expands port specs such as "3000", "8080-8090" or "3000,5173"
+// into a sorted list of unique ports.
+func ParsePorts(specs []string) ([]int, error) {
+	seen := map[int]bool{}
+	for _, spec := range specs {
+		for _, part := range strings.Split(spec, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			lo, hi, isRange := strings.Cut(part, "-")
+			first, err := parsePort(lo)
+			if err != nil {
+				return nil, err
+			}
+			last := first
+			if isRange {
+				if last, err = parsePort(hi); err != nil {
+					return nil, err
+				}
+				if last < first {
+					return nil, fmt.Errorf("invalid port range %q", part)
+				}
+			}
+			for p := first; p <= last; p++ {
+				seen[p] = true
+			}
+		}
+	}
+
+	ports := make([]int, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// FormatPorts is the inverse of ParsePorts: it joins sorted ports with commas,
+// collapsing consecutive runs into ranges (e.g. "3000,8080-8090").
+func FormatPorts(ports []int) string {
+	var parts []string
+	for i := 0; i < len(ports); {
+		j := i
+		for j+1 < len(ports) && ports[j+1] == ports[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", ports[i], ports[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(ports[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func parsePort(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return p, nil
+}